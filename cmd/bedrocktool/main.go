@@ -26,6 +26,13 @@ import (
 	_ "github.com/bedrock-tool/bedrocktool/subcommands/render"
 	_ "github.com/bedrock-tool/bedrocktool/subcommands/skins"
 	_ "github.com/bedrock-tool/bedrocktool/subcommands/world"
+	_ "github.com/bedrock-tool/bedrocktool/subcommands/worldinventory"
+	_ "github.com/bedrock-tool/bedrocktool/subcommands/worldprune"
+	_ "github.com/bedrock-tool/bedrocktool/subcommands/worldremap"
+	_ "github.com/bedrock-tool/bedrocktool/subcommands/worldsearch"
+	_ "github.com/bedrock-tool/bedrocktool/subcommands/worldstats"
+	_ "github.com/bedrock-tool/bedrocktool/subcommands/worldtext"
+	_ "github.com/bedrock-tool/bedrocktool/subcommands/worldvalidate"
 
 	"github.com/sirupsen/logrus"
 )
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/proxy"
+	"github.com/flytam/filenamify"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sirupsen/logrus"
+)
+
+var creativeCategoryNames = map[int32]string{
+	protocol.CreativeCategoryAll:             "all",
+	protocol.CreativeCategoryConstruction:    "construction",
+	protocol.CreativeCategoryNature:          "nature",
+	protocol.CreativeCategoryEquipment:       "equipment",
+	protocol.CreativeCategoryItems:           "items",
+	protocol.CreativeCategoryItemCommandOnly: "command_only",
+	protocol.CreativeCategoryUndefined:       "undefined",
+}
+
+type catalogueItem struct {
+	Identifier  string         `json:"identifier"`
+	RuntimeID   int32          `json:"runtime_id"`
+	Metadata    uint32         `json:"metadata,omitempty"`
+	DisplayName string         `json:"display_name,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	Custom      bool           `json:"custom"`
+	NBT         map[string]any `json:"nbt,omitempty"`
+}
+
+type catalogueGroup struct {
+	Name     string          `json:"name"`
+	Category string          `json:"category"`
+	Icon     string          `json:"icon,omitempty"`
+	Items    []catalogueItem `json:"items"`
+}
+
+type catalogue struct {
+	Server string           `json:"server"`
+	Time   time.Time        `json:"time"`
+	Groups []catalogueGroup `json:"groups"`
+}
+
+type itemCatalogue struct {
+	log        *logrus.Entry
+	serverName string
+	items      map[int32]protocol.ItemEntry
+	creative   *packet.CreativeContent
+}
+
+func (c *itemCatalogue) PacketCB(session *proxy.Session, pk packet.Packet, toServer bool, t time.Time, _ bool) (packet.Packet, error) {
+	switch pk := pk.(type) {
+	case *packet.ItemRegistry:
+		for _, ie := range pk.Items {
+			c.items[int32(ie.RuntimeID)] = ie
+		}
+	case *packet.CreativeContent:
+		c.creative = pk
+	}
+	return pk, nil
+}
+
+// iconName returns the item_texture.json short name a custom item uses
+func iconName(ie protocol.ItemEntry) string {
+	components, _ := ie.Data["components"].(map[string]any)
+	if components == nil {
+		return ""
+	}
+	icon, _ := components["minecraft:icon"].(map[string]any)
+	if icon == nil {
+		if properties, ok := components["item_properties"].(map[string]any); ok {
+			icon, _ = properties["minecraft:icon"].(map[string]any)
+		}
+	}
+	if icon == nil {
+		return ""
+	}
+	if textures, ok := icon["textures"].(map[string]any); ok {
+		name, _ := textures["default"].(string)
+		return name
+	}
+	name, _ := icon["texture"].(string)
+	return name
+}
+
+func displayName(ie protocol.ItemEntry, lang map[string]string) string {
+	if components, ok := ie.Data["components"].(map[string]any); ok {
+		if dn, ok := components["minecraft:display_name"].(map[string]any); ok {
+			if value, ok := dn["value"].(string); ok {
+				if translated, ok := lang[value]; ok {
+					return translated
+				}
+				return value
+			}
+		}
+	}
+	_, name, _ := strings.Cut(ie.Name, ":")
+	for _, key := range []string{
+		"item." + ie.Name + ".name",
+		"tile." + ie.Name + ".name",
+		"item." + name + ".name",
+		"tile." + name + ".name",
+	} {
+		if translated, ok := lang[key]; ok {
+			return translated
+		}
+	}
+	return ""
+}
+
+func (c *itemCatalogue) save(session *proxy.Session) error {
+	if c.creative == nil {
+		return nil
+	}
+	serverName, _ := filenamify.FilenamifyV2(c.serverName)
+	outDir := path.Join("items", serverName)
+	if err := os.MkdirAll(path.Join(outDir, "icons"), 0o755); err != nil {
+		return err
+	}
+
+	packs := session.Server.ResourcePacks()
+	lang := utils.LoadLang(packs, "en_US")
+	iconNames := make(map[string]string)
+	for _, ie := range c.items {
+		if name := iconName(ie); name != "" {
+			iconNames[ie.Name] = name
+		}
+	}
+	icons := utils.ResolveItemIcons(iconNames, packs)
+
+	iconFiles := make(map[string]string)
+	iconFile := func(identifier string) string {
+		if f, ok := iconFiles[identifier]; ok {
+			return f
+		}
+		icon, ok := icons[identifier]
+		if !ok {
+			return ""
+		}
+		ns, name, _ := strings.Cut(identifier, ":")
+		f := path.Join("icons", ns+"_"+name+path.Ext(icon.Path))
+		err := os.WriteFile(path.Join(outDir, f), icon.Data, 0o644)
+		if err != nil {
+			c.log.Error(err)
+			return ""
+		}
+		iconFiles[identifier] = f
+		return f
+	}
+
+	toItem := func(stack protocol.ItemStack) (catalogueItem, bool) {
+		ie, ok := c.items[stack.NetworkID]
+		if !ok {
+			return catalogueItem{}, false
+		}
+		return catalogueItem{
+			Identifier:  ie.Name,
+			RuntimeID:   stack.NetworkID,
+			Metadata:    stack.MetadataValue,
+			DisplayName: displayName(ie, lang),
+			Icon:        iconFile(ie.Name),
+			Custom:      ie.ComponentBased,
+			NBT:         stack.NBTData,
+		}, true
+	}
+
+	out := catalogue{
+		Server: c.serverName,
+		Time:   time.Now(),
+	}
+	for _, group := range c.creative.Groups {
+		g := catalogueGroup{
+			Name:     group.Name,
+			Category: creativeCategoryNames[group.Category],
+			Items:    []catalogueItem{},
+		}
+		if icon, ok := toItem(group.Icon); ok {
+			g.Icon = icon.Identifier
+		}
+		out.Groups = append(out.Groups, g)
+	}
+	// items without a valid group
+	ungrouped := -1
+
+	var unknown int
+	for _, ci := range c.creative.Items {
+		item, ok := toItem(ci.Item)
+		if !ok {
+			unknown++
+			continue
+		}
+		idx := int(ci.GroupIndex)
+		if idx >= len(out.Groups) {
+			if ungrouped < 0 {
+				ungrouped = len(out.Groups)
+				out.Groups = append(out.Groups, catalogueGroup{
+					Category: creativeCategoryNames[protocol.CreativeCategoryUndefined],
+					Items:    []catalogueItem{},
+				})
+			}
+			idx = ungrouped
+		}
+		out.Groups[idx].Items = append(out.Groups[idx].Items, item)
+	}
+	if unknown > 0 {
+		c.log.Warnf("%d creative items with unknown runtime ids", unknown)
+	}
+
+	f, err := os.Create(path.Join(outDir, "items.json"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	e := json.NewEncoder(f)
+	e.SetIndent("", "\t")
+	err = e.Encode(out)
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Wrote %d creative items to %s", len(c.creative.Items)-unknown, f.Name())
+	return nil
+}
+
+// NewItemCatalogue exports the creative inventory of the server with names and icons from its resource packs
+func NewItemCatalogue() func() *proxy.Handler {
+	return func() *proxy.Handler {
+		c := &itemCatalogue{
+			log:   logrus.WithField("part", "ItemCatalogue"),
+			items: make(map[int32]protocol.ItemEntry),
+		}
+		return &proxy.Handler{
+			Name:           "Item Catalogue",
+			PacketCallback: c.PacketCB,
+			SessionStart: func(s *proxy.Session, serverName string) error {
+				c.serverName = serverName
+				return nil
+			},
+			OnSessionEnd: func(s *proxy.Session) {
+				err := c.save(s)
+				if err != nil {
+					c.log.Error(err)
+				}
+			},
+		}
+	}
+}
@@ -37,6 +37,14 @@ type Entity struct {
 	Chestplate *protocol.ItemInstance
 	Leggings   *protocol.ItemInstance
 	Boots      *protocol.ItemInstance
+
+	// trades sent with UpdateTrade
+	Offers    map[string]any
+	TradeTier int32
+
+	// npc dialogue sent with NPCDialogue
+	NPCDialogue string
+	NPCActions  string
 }
 
 type EntityProperty struct {
@@ -235,6 +243,20 @@ func (s *Entity) toNBT(nbt map[string]any) {
 	}
 
 	nbt["Attributes"] = attributes
+
+	if s.Offers != nil {
+		nbt["Offers"] = s.Offers
+		nbt["TradeTier"] = s.TradeTier
+	}
+
+	if s.NPCDialogue != "" || s.NPCActions != "" {
+		actions := s.NPCActions
+		if actions == "" {
+			actions = "[]"
+		}
+		nbt["InteractiveText"] = s.NPCDialogue
+		nbt["Actions"] = actions
+	}
 }
 
 func vec3float32(x mgl32.Vec3) []float32 {
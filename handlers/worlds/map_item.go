@@ -79,6 +79,9 @@ type MapUI struct {
 	w              *worldsHandler
 
 	ChunkRenderer *utils.ChunkRenderer
+	// cache of the rendered chunks of the current world, nil when it couldnt be opened
+	renderCache *utils.RenderCache
+	cacheDim    int
 
 	l          sync.Mutex
 	haveColors chan struct{}
@@ -131,7 +134,10 @@ func (m *MapUI) Start(ctx context.Context) {
 		return
 	}
 
+	messages.Router.AddHandler("mapui", m.HandleMessage)
+
 	m.ticker = time.NewTicker(33 * time.Millisecond)
+	m.ChunkRenderer.Biomes = m.w.serverState.biomes
 	go func() {
 		m.ChunkRenderer.ResolveColors(m.w.serverState.customBlocks, m.w.session.Server.ResourcePacks())
 		close(m.haveColors)
@@ -188,6 +194,7 @@ func (m *MapUI) Start(ctx context.Context) {
 }
 
 func (m *MapUI) Stop() {
+	messages.Router.RemoveHandler("mapui")
 	if m.ticker != nil {
 		m.ticker.Stop()
 	}
@@ -209,6 +216,61 @@ func (m *MapUI) Reset() {
 	m.SchedRedraw()
 }
 
+// OpenCache uses the render cache in folder for the chunks of dim,
+// so a world that is resumed doesnt have to render chunks that didnt change again
+func (m *MapUI) OpenCache(folder string, dim int) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.closeCacheLocked()
+	cache, err := utils.OpenRenderCache(folder)
+	if err != nil {
+		m.log.Warnf("render cache: %s", err)
+		return
+	}
+	m.renderCache = cache
+	m.cacheDim = dim
+}
+
+func (m *MapUI) CloseCache() {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.closeCacheLocked()
+}
+
+func (m *MapUI) closeCacheLocked() {
+	if m.renderCache == nil {
+		return
+	}
+	if err := m.renderCache.Close(); err != nil {
+		m.log.Warnf("render cache: %s", err)
+	}
+	m.renderCache = nil
+}
+
+// SetRenderMode changes how chunks are drawn, all chunks that were drawn before are removed
+func (m *MapUI) SetRenderMode(mode utils.RenderMode, sliceY int16) {
+	m.l.Lock()
+	m.ChunkRenderer.Mode = mode
+	m.ChunkRenderer.SliceY = sliceY
+	m.l.Unlock()
+	m.Reset()
+}
+
+func (m *MapUI) HandleMessage(msg *messages.Message) *messages.Message {
+	switch data := msg.Data.(type) {
+	case messages.SetValue:
+		if data.Name == "renderMode" {
+			mode, err := utils.ParseRenderMode(data.Value)
+			if err != nil {
+				m.log.Error(err)
+				return nil
+			}
+			m.w.setRenderMode(mode, int16(math.Floor(float64(m.w.session.Player.Position.Y()))))
+		}
+	}
+	return nil
+}
+
 // ChangeZoom adds to the zoom value and goes around to 32 once it hits 128
 func (m *MapUI) ChangeZoom() {
 	m.zoomLevel /= 2
@@ -235,7 +297,12 @@ func (m *MapUI) processQueue() []protocol.ChunkPos {
 			break
 		}
 		if r.ch != nil {
-			img := m.ChunkRenderer.Chunk2Img(r.ch)
+			var img *image.RGBA
+			if m.renderCache != nil && !r.isDeferredState {
+				img = m.renderCache.Chunk2Img(m.ChunkRenderer, m.cacheDim, world.ChunkPos(r.pos), r.ch)
+			} else {
+				img = m.ChunkRenderer.Chunk2Img(r.ch)
+			}
 			if r.isDeferredState {
 				if old, ok := m.renderedChunks[r.pos]; ok {
 					m.oldRendered[r.pos] = old
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/bedrock-tool/bedrocktool/handlers/worlds/entity"
+	"github.com/bedrock-tool/bedrocktool/handlers/worlds/scripting"
 	"github.com/bedrock-tool/bedrocktool/handlers/worlds/worldstate"
 	"github.com/bedrock-tool/bedrocktool/locale"
 	"github.com/bedrock-tool/bedrocktool/utils"
@@ -24,6 +25,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// playerEyeHeight is how far above the feet the positions of the local player are
+const playerEyeHeight = 1.62
+
 func (w *worldsHandler) packetHandlerPreLogin(_pk packet.Packet, timeReceived time.Time) (packet.Packet, error) {
 	switch pk := _pk.(type) {
 	case *packet.GameRulesChanged:
@@ -48,6 +52,18 @@ func (w *worldsHandler) packetHandlerPreLogin(_pk packet.Packet, timeReceived ti
 		if !w.serverState.haveStartGame {
 			w.serverState.haveStartGame = true
 			w.serverState.useHashedRids = pk.UseBlockNetworkIDHashes
+			w.serverState.playerUniqueID = pk.EntityUniqueID
+			w.serverState.playerGameMode = pk.PlayerGameMode
+			if pk.PlayerGameMode == packet.GameTypeDefault {
+				w.serverState.playerGameMode = pk.WorldGameMode
+			}
+			w.serverState.level = worldstate.LevelSettings{
+				RainLevel:      &pk.RainLevel,
+				LightningLevel: &pk.LightningLevel,
+				Difficulty:     &pk.Difficulty,
+				GameType:       &pk.WorldGameMode,
+			}
+			w.serverState.worldSpawns[0] = cube.Pos{int(pk.WorldSpawn.X()), int(pk.WorldSpawn.Y()), int(pk.WorldSpawn.Z())}
 
 			var haveGameRule = false
 			for i, gameRule := range pk.GameRules {
@@ -105,6 +121,11 @@ func (w *worldsHandler) packetHandlerPreLogin(_pk packet.Packet, timeReceived ti
 		}
 		w.serverState.behaviorPack.ApplyComponentEntries(pk.Items)
 
+	case *packet.CraftingData:
+		for _, err := range w.serverState.behaviorPack.AddRecipes(pk.Recipes) {
+			w.log.WithField("packet", "CraftingData").Warn(err)
+		}
+
 	case *packet.BiomeDefinitionList:
 		var biomes map[string]any
 		err := nbt.UnmarshalEncoding(pk.SerialisedBiomeDefinitions, &biomes, nbt.NetworkLittleEndian)
@@ -145,9 +166,22 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 			world.SetTime(timeReceived, int(pk.Time))
 		})
 
+	case *packet.PlayerAuthInput:
+		w.currentWorld(func(world *worldstate.World) {
+			world.RecordPlayerPosition(timeReceived, pk.Position.Sub(mgl32.Vec3{0, playerEyeHeight, 0}))
+		})
+
+	case *packet.MovePlayer:
+		if pk.EntityRuntimeID == w.session.Player.RuntimeID {
+			w.currentWorld(func(world *worldstate.World) {
+				world.RecordPlayerPosition(timeReceived, pk.Position.Sub(mgl32.Vec3{0, playerEyeHeight, 0}))
+			})
+		}
+
 	// chunk
 
 	case *packet.ChangeDimension:
+		w.scripting.OnChangeDimension(pk.Dimension, pk.Position, timeReceived)
 		dim, _ := world.DimensionByID(int(pk.Dimension))
 		w.SaveAndReset(false, dim)
 
@@ -196,9 +230,39 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 		if pk.ActionType == packet.PlayerListActionAdd {
 			for _, player := range pk.Entries {
 				w.serverState.playerSkins[player.UUID] = &player.Skin
+				w.serverState.playerNames[player.EntityUniqueID] = player.Username
+				p := scripting.Player{
+					UUID:           player.UUID.String(),
+					Username:       player.Username,
+					XUID:           player.XUID,
+					EntityUniqueID: player.EntityUniqueID,
+				}
+				w.serverState.playerList[player.UUID] = p
+				w.scripting.OnPlayerAdd(p, timeReceived)
+			}
+		} else {
+			for _, player := range pk.Entries {
+				p, ok := w.serverState.playerList[player.UUID]
+				if !ok {
+					p = scripting.Player{UUID: player.UUID.String()}
+				}
+				delete(w.serverState.playerList, player.UUID)
+				w.scripting.OnPlayerRemove(p, timeReceived)
 			}
 		}
 
+	case *packet.Text:
+		w.scripting.OnText(pk, toServer, timeReceived)
+
+	case *packet.SetDisplayObjective:
+		w.serverState.scoreboard.SetDisplayObjective(pk)
+	case *packet.RemoveObjective:
+		w.serverState.scoreboard.RemoveObjective(pk)
+	case *packet.SetScore:
+		w.serverState.scoreboard.SetScore(pk)
+	case *packet.SetScoreboardIdentity:
+		w.serverState.scoreboard.SetScoreboardIdentity(pk)
+
 	case *packet.PlayerSkin:
 		w.serverState.playerSkins[pk.UUID] = &pk.Skin
 
@@ -236,18 +300,13 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 			w.serverState.behaviorPack.AddEntity(pk.EntityType, pk.Attributes, ent.Metadata, ent.Properties)
 		})
 
-		/*
-			case *packet.RemoveActor:
-				entity := w.currentWorld.GetEntityUniqueID(pk.EntityUniqueID)
-				if entity != nil {
-						dist := entity.Position.Vec2().Sub(playerPos.Vec2()).Len()
-
-						fmt.Fprintf(distf, "%.5f\t%s\n", dist, entity.EntityType)
-
-						_ = dist
-						println()
-				}
-		*/
+	case *packet.RemoveActor:
+		// entities stay in the world when they leave the render distance
+		w.currentWorld(func(world *worldstate.World) {
+			if e := world.GetEntityUniqueID(pk.EntityUniqueID); e != nil {
+				w.scripting.OnEntityRemove(e, timeReceived)
+			}
+		})
 
 	case *packet.SetActorData:
 		w.currentWorld(func(world *worldstate.World) {
@@ -301,6 +360,9 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 		})
 
 	case *packet.MobEquipment:
+		if pk.EntityRuntimeID == w.session.Player.RuntimeID && pk.WindowID == protocol.WindowIDInventory {
+			w.serverState.selectedSlot = pk.HotBarSlot
+		}
 		if pk.NewItem.Stack.NBTData["map_uuid"] == int64(ViewMapID) {
 			_pk = nil
 		} else {
@@ -326,11 +388,84 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 			}
 		})
 
+	case *packet.PlayerHotBar:
+		if pk.WindowID == protocol.WindowIDInventory {
+			w.serverState.selectedSlot = byte(pk.SelectedHotBarSlot)
+		}
+
+	case *packet.SetPlayerGameType:
+		w.serverState.playerGameMode = pk.GameType
+
+	case *packet.SetDefaultGameType:
+		w.serverState.level.GameType = &pk.GameType
+
+	case *packet.SetDifficulty:
+		difficulty := int32(pk.Difficulty)
+		w.serverState.level.Difficulty = &difficulty
+
+	case *packet.SetSpawnPosition:
+		if pk.SpawnType == packet.SpawnTypeWorld {
+			w.serverState.worldSpawns[int(pk.Dimension)] = cube.Pos{int(pk.Position.X()), int(pk.Position.Y()), int(pk.Position.Z())}
+		}
+
+	case *packet.LevelEvent:
+		switch pk.EventType {
+		case packet.LevelEventStartRaining:
+			level := float32(pk.EventData) / 65535
+			w.serverState.level.RainLevel = &level
+		case packet.LevelEventStopRaining:
+			level := float32(0)
+			w.serverState.level.RainLevel = &level
+		case packet.LevelEventStartThunderstorm:
+			level := float32(pk.EventData) / 65535
+			w.serverState.level.LightningLevel = &level
+		case packet.LevelEventStopThunderstorm:
+			level := float32(0)
+			w.serverState.level.LightningLevel = &level
+		}
+
+	case *packet.UpdateAttributes:
+		if pk.EntityRuntimeID == w.session.Player.RuntimeID {
+			for _, attr := range pk.Attributes {
+				switch attr.Name {
+				case "minecraft:player.level":
+					w.serverState.playerLevel = int32(attr.Value)
+				case "minecraft:player.experience":
+					w.serverState.playerXPProgress = attr.Value
+				}
+			}
+		}
+
 	case *packet.SetActorLink:
 		w.currentWorld(func(world *worldstate.World) {
 			world.AddEntityLink(pk.EntityLink)
 		})
 
+	case *packet.UpdateTrade:
+		var offers map[string]any
+		err := nbt.UnmarshalEncoding(pk.SerialisedOffers, &offers, nbt.NetworkLittleEndian)
+		if err != nil {
+			w.log.WithField("packet", "UpdateTrade").Error(err)
+			break
+		}
+		w.currentWorld(func(world *worldstate.World) {
+			if e := world.GetEntityUniqueID(pk.VillagerUniqueID); e != nil {
+				e.Offers = offers
+				e.TradeTier = pk.TradeTier
+			}
+		})
+
+	case *packet.NPCDialogue:
+		if pk.ActionType != packet.NPCDialogueActionOpen {
+			break
+		}
+		w.currentWorld(func(world *worldstate.World) {
+			if e := world.GetEntityUniqueID(int64(pk.EntityUniqueID)); e != nil {
+				e.NPCDialogue = pk.Dialogue
+				e.NPCActions = pk.ActionJSON
+			}
+		})
+
 	case *packet.ItemStackRequest:
 		var requests []protocol.ItemStackRequest
 		for _, isr := range pk.Requests {
@@ -385,13 +520,21 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 		switch pk.WindowID {
 		case 0:
 			w.serverState.playerInventory = pk.Content
+		case protocol.WindowIDArmour:
+			w.serverState.playerArmour = pk.Content
 		case protocol.WindowIDOffHand:
+			// the offhand is used for the map, keep what the server sent for saving
+			w.serverState.playerOffhand = pk.Content
 			_pk = nil
 		default:
 			// save content
 			existing, ok := w.serverState.openItemContainers[byte(pk.WindowID)]
 			if ok {
 				existing.Content = pk
+				if !existing.scriptNotified {
+					existing.scriptNotified = true
+					w.scripting.OnContainerOpen(w.scriptContainer(existing), w.scriptContainerItems(pk.Content), timeReceived)
+				}
 			}
 		}
 
@@ -402,7 +545,15 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 				w.serverState.playerInventory = make([]protocol.ItemInstance, 36)
 			}
 			w.serverState.playerInventory[pk.Slot] = pk.NewItem
+		case protocol.WindowIDArmour:
+			if w.serverState.playerArmour == nil {
+				w.serverState.playerArmour = make([]protocol.ItemInstance, 4)
+			}
+			if int(pk.Slot) < len(w.serverState.playerArmour) {
+				w.serverState.playerArmour[pk.Slot] = pk.NewItem
+			}
 		case protocol.WindowIDOffHand:
+			w.serverState.playerOffhand = []protocol.ItemInstance{pk.NewItem}
 			_pk = nil
 		default:
 			// save content
@@ -417,8 +568,8 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 		existing, ok := w.serverState.openItemContainers[byte(pk.WindowID)]
 
 		switch pk.WindowID {
-		case protocol.WindowIDArmour: // todo handle
-		case protocol.WindowIDOffHand: // todo handle
+		case protocol.WindowIDArmour: // tracked through InventoryContent
+		case protocol.WindowIDOffHand: // tracked through InventoryContent
 		case protocol.WindowIDUI:
 		case protocol.WindowIDInventory: // todo handle
 			if !ok {
@@ -435,6 +586,21 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 				break
 			}
 
+			p := existing.OpenPacket.ContainerPosition
+			pos := cube.Pos{int(p.X()), int(p.Y()), int(p.Z())}
+
+			// ender chest contents belong to the player, not the block
+			var isEnderChest bool
+			w.currentWorld(func(world *worldstate.World) {
+				name, _, found := world.BlockAt(pos)
+				isEnderChest = found && name == "minecraft:ender_chest"
+			})
+			if isEnderChest {
+				w.serverState.playerEnderChest = existing.Content.Content
+				delete(w.serverState.openItemContainers, byte(pk.WindowID))
+				break
+			}
+
 			// create inventory
 			inv := inventory.New(len(existing.Content.Content), nil)
 			for i, c := range existing.Content.Content {
@@ -444,8 +610,6 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 
 			// put into subchunk
 			w.currentWorld(func(world *worldstate.World) {
-				p := existing.OpenPacket.ContainerPosition
-				pos := cube.Pos{int(p.X()), int(p.Y()), int(p.Z())}
 				world.SetBlockNBT(pos, map[string]any{
 					"Items": nbtconv.InvToNBT(inv),
 				}, true)
@@ -504,7 +668,7 @@ func (w *worldsHandler) packetHandlerIngame(_pk packet.Packet, toServer bool, ti
 }
 
 func (w *worldsHandler) packetHandler(_ *proxy.Session, pk packet.Packet, toServer bool, timeReceived time.Time, preLogin bool) (packet.Packet, error) {
-	drop := w.scripting.OnPacket(pk, toServer, timeReceived)
+	pk, drop := w.scripting.OnPacket(pk, toServer, timeReceived)
 	if drop {
 		return nil, nil
 	}
@@ -645,3 +809,32 @@ func (w *worldsHandler) addPlayer(pk *packet.AddPlayer) {
 		w.serverState.resourcePack.AddPlayer(pk.UUID.String(), skinTexture, capeTexture, skin.CapeID, geometry, isDefault)
 	}
 }
+
+func (w *worldsHandler) scriptContainer(c *itemContainer) scripting.Container {
+	return scripting.Container{
+		WindowID:       c.OpenPacket.WindowID,
+		ContainerType:  c.OpenPacket.ContainerType,
+		Position:       c.OpenPacket.ContainerPosition,
+		EntityUniqueID: c.OpenPacket.ContainerEntityUniqueID,
+	}
+}
+
+// scriptContainerItems resolves the items of a container to their names, empty slots are left out
+func (w *worldsHandler) scriptContainerItems(content []protocol.ItemInstance) []scripting.ContainerItem {
+	items := make([]scripting.ContainerItem, 0, len(content))
+	for slot, instance := range content {
+		stack := utils.StackToItem(w.serverState.blocks, instance.Stack)
+		if stack.Empty() {
+			continue
+		}
+		name, meta := stack.Item().EncodeItem()
+		items = append(items, scripting.ContainerItem{
+			Slot:     slot,
+			Name:     name,
+			Metadata: int(meta),
+			Count:    stack.Count(),
+			NBT:      instance.Stack.NBTData,
+		})
+	}
+	return items
+}
@@ -3,7 +3,9 @@ package worlds
 import (
 	"github.com/bedrock-tool/bedrocktool/utils"
 	"github.com/bedrock-tool/bedrocktool/utils/nbtconv"
+	"github.com/df-mc/dragonfly/server/item"
 	"github.com/df-mc/dragonfly/server/item/inventory"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 func (w *worldsHandler) playerData() (ret map[string]any) {
@@ -20,6 +22,28 @@ func (w *worldsHandler) playerData() (ret map[string]any) {
 		ret["Inventory"] = nbtconv.InvToNBT(inv)
 	}
 
+	if w.settings.SaveInventories {
+		if len(w.serverState.playerArmour) > 0 {
+			ret["Armor"] = w.itemList(w.serverState.playerArmour, 4)
+		}
+		if len(w.serverState.playerOffhand) > 0 {
+			ret["Offhand"] = w.itemList(w.serverState.playerOffhand, 1)
+		}
+		if len(w.serverState.playerEnderChest) > 0 {
+			inv := inventory.New(len(w.serverState.playerEnderChest), nil)
+			for i, ii := range w.serverState.playerEnderChest {
+				inv.SetItem(i, utils.StackToItem(w.serverState.blocks, ii.Stack))
+			}
+			ret["EnderChestInventory"] = nbtconv.InvToNBT(inv)
+		}
+	}
+
+	ret["SelectedInventorySlot"] = int32(w.serverState.selectedSlot)
+	ret["SelectedContainerId"] = int32(0)
+	ret["PlayerLevel"] = w.serverState.playerLevel
+	ret["PlayerLevelProgress"] = w.serverState.playerXPProgress
+	ret["PlayerGameMode"] = w.serverState.playerGameMode
+
 	ret["abilities"] = map[string]any{
 		"doorsandswitches":       true,
 		"op":                     true,
@@ -161,7 +185,7 @@ func (w *worldsHandler) playerData() (ret map[string]any) {
 		},
 		{
 			Base:       0,
-			Current:    0,
+			Current:    float32(w.serverState.playerLevel),
 			DefaultMax: 24791,
 			DefaultMin: 0,
 			Max:        24791,
@@ -170,7 +194,7 @@ func (w *worldsHandler) playerData() (ret map[string]any) {
 		},
 		{
 			Base:       0,
-			Current:    0,
+			Current:    w.serverState.playerXPProgress,
 			DefaultMax: 1,
 			DefaultMin: 0,
 			Max:        1,
@@ -179,6 +203,9 @@ func (w *worldsHandler) playerData() (ret map[string]any) {
 		},
 	}
 
+	// scoreboard entries of the local player refer to this
+	ret["UniqueID"] = w.serverState.playerUniqueID
+
 	ret["Tags"] = []string{}
 	ret["OnGround"] = true
 
@@ -201,3 +228,24 @@ func (w *worldsHandler) playerData() (ret map[string]any) {
 
 	return
 }
+
+// itemList writes a fixed size item list without slot numbers, as used for armour and offhand
+func (w *worldsHandler) itemList(items []protocol.ItemInstance, size int) []map[string]any {
+	out := make([]map[string]any, size)
+	for i := range out {
+		var stack item.Stack
+		if i < len(items) {
+			stack = utils.StackToItem(w.serverState.blocks, items[i].Stack)
+		}
+		if stack.Empty() {
+			out[i] = map[string]any{
+				"Name":   "",
+				"Count":  byte(0),
+				"Damage": int16(0),
+			}
+			continue
+		}
+		out[i] = nbtconv.WriteItem(stack, true)
+	}
+	return out
+}
@@ -0,0 +1,205 @@
+package scripting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// commandArgTypes are the parameter types scripts can use, text takes the rest of the command line
+var commandArgTypes = map[string]uint32{
+	"int":      protocol.CommandArgTypeInt,
+	"float":    protocol.CommandArgTypeFloat,
+	"string":   protocol.CommandArgTypeString,
+	"target":   protocol.CommandArgTypeTarget,
+	"position": protocol.CommandArgTypePosition,
+	"text":     protocol.CommandArgTypeRawText,
+}
+
+// commandParam is a parameter of a command registered by a script
+type commandParam struct {
+	name     string
+	typ      string
+	optional bool
+}
+
+type scriptCommand struct {
+	name     string
+	params   []commandParam
+	callback goja.Callable
+}
+
+func (c *scriptCommand) usage() string {
+	var b strings.Builder
+	b.WriteString("/" + c.name)
+	for _, p := range c.params {
+		if p.optional {
+			fmt.Fprintf(&b, " [%s: %s]", p.name, p.typ)
+		} else {
+			fmt.Fprintf(&b, " <%s: %s>", p.name, p.typ)
+		}
+	}
+	return b.String()
+}
+
+// parseCommandParams reads the params array of commands.register, [{name: 'radius', type: 'int', optional: true}]
+func parseCommandParams(value goja.Value) ([]commandParam, error) {
+	if goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil, nil
+	}
+	list, ok := value.Export().([]any)
+	if !ok {
+		return nil, fmt.Errorf("params has to be an array")
+	}
+	var params []commandParam
+	for i, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("param %d has to be an object", i)
+		}
+		var p commandParam
+		p.name, _ = m["name"].(string)
+		p.typ, _ = m["type"].(string)
+		p.optional, _ = m["optional"].(bool)
+		if p.name == "" {
+			return nil, fmt.Errorf("param %d has no name", i)
+		}
+		if _, ok := commandArgTypes[p.typ]; !ok {
+			return nil, fmt.Errorf("param %s has an unknown type %q", p.name, p.typ)
+		}
+		if len(params) > 0 && params[len(params)-1].typ == "text" {
+			return nil, fmt.Errorf("param %s is after a text param", p.name)
+		}
+		if len(params) > 0 && params[len(params)-1].optional && !p.optional {
+			return nil, fmt.Errorf("param %s is required but after an optional param", p.name)
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// registerCommand adds a command to the session, the client only gets the list of commands once
+// when it joins so commands can only be registered while the script loads
+func (v *VM) registerCommand(name, description string, params goja.Value, callback goja.Callable) error {
+	if v.loaded {
+		return fmt.Errorf("command %s has to be registered when the script loads, not later", name)
+	}
+	if name == "" || strings.ContainsAny(name, " /") || strings.ToLower(name) != name {
+		// the client crashes with uppercase command names
+		return fmt.Errorf("invalid command name %q, use lowercase without spaces", name)
+	}
+	if callback == nil {
+		return fmt.Errorf("command %s has no callback", name)
+	}
+	cmdParams, err := parseCommandParams(params)
+	if err != nil {
+		return fmt.Errorf("command %s: %w", name, err)
+	}
+
+	cmd := &scriptCommand{name: name, params: cmdParams, callback: callback}
+	overload := protocol.CommandOverload{}
+	for _, p := range cmdParams {
+		overload.Parameters = append(overload.Parameters, protocol.CommandParameter{
+			Name:     p.name,
+			Type:     protocol.CommandArgValid | commandArgTypes[p.typ],
+			Optional: p.optional,
+		})
+	}
+	v.session.AddCommand(func(args []string) bool {
+		return v.runCommand(cmd, args)
+	}, protocol.Command{
+		Name:        name,
+		Description: description,
+		Overloads:   []protocol.CommandOverload{overload},
+	})
+	return nil
+}
+
+// parseCommandArgs converts the arguments of a command line to the values of the params,
+// positions starting with ~ are relative to the player
+func (v *VM) parseCommandArgs(params []commandParam, args []string) (map[string]any, error) {
+	out := make(map[string]any, len(params))
+	for _, p := range params {
+		if len(args) == 0 {
+			if p.optional {
+				break
+			}
+			return nil, fmt.Errorf("missing %s", p.name)
+		}
+		switch p.typ {
+		case "int":
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("%s has to be a whole number", p.name)
+			}
+			out[p.name] = n
+		case "float":
+			f, err := strconv.ParseFloat(args[0], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s has to be a number", p.name)
+			}
+			out[p.name] = f
+		case "string", "target":
+			out[p.name] = args[0]
+		case "position":
+			if len(args) < 3 {
+				return nil, fmt.Errorf("%s needs x y z", p.name)
+			}
+			var pos [3]float64
+			for i := range pos {
+				coord := args[i]
+				if relative, ok := strings.CutPrefix(coord, "~"); ok {
+					pos[i] = float64(v.session.Player.Position[i])
+					coord = relative
+				}
+				if coord == "" {
+					continue
+				}
+				f, err := strconv.ParseFloat(coord, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%s has to be x y z", p.name)
+				}
+				pos[i] += f
+			}
+			out[p.name] = pos[:]
+			args = args[2:]
+		case "text":
+			out[p.name] = strings.Join(args, " ")
+			args = nil
+			continue
+		}
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("too many arguments")
+	}
+	return out, nil
+}
+
+// runCommand calls the script with the parsed arguments and a function to reply to the player
+func (v *VM) runCommand(cmd *scriptCommand, cmdline []string) bool {
+	var args []string
+	for _, arg := range cmdline {
+		if arg != "" {
+			args = append(args, arg)
+		}
+	}
+	parsed, err := v.parseCommandArgs(cmd.params, args)
+	if err != nil {
+		v.session.SendMessage(fmt.Sprintf("%s, usage: %s", err, cmd.usage()))
+		return false
+	}
+
+	err = v.run(func() error {
+		_, err := cmd.callback(goja.Undefined(), v.runtime.ToValue(parsed), v.runtime.ToValue(v.session.SendMessage))
+		return err
+	})
+	if err != nil {
+		v.log.Errorf("command %s: %s", cmd.name, err)
+		return false
+	}
+	return true
+}
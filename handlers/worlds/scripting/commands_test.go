@@ -0,0 +1,38 @@
+package scripting
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommandArgs(t *testing.T) {
+	params := []commandParam{
+		{name: "radius", typ: "int"},
+		{name: "pos", typ: "position", optional: true},
+		{name: "note", typ: "text", optional: true},
+	}
+	v := &VM{}
+
+	args, err := v.parseCommandArgs(params, []string{"5", "1", "64", "-2.5", "my", "base"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"radius": 5, "pos": []float64{1, 64, -2.5}, "note": "my base"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("got %v, want %v", args, want)
+	}
+
+	args, err = v.parseCommandArgs(params, []string{"5"})
+	if err != nil || len(args) != 1 {
+		t.Errorf("optional params: got %v %v", args, err)
+	}
+	if _, err := v.parseCommandArgs(params, nil); err == nil {
+		t.Error("expected an error for a missing param")
+	}
+	if _, err := v.parseCommandArgs(params, []string{"five"}); err == nil {
+		t.Error("expected an error for a bad int")
+	}
+	if _, err := v.parseCommandArgs(params[:1], []string{"5", "6"}); err == nil {
+		t.Error("expected an error for too many arguments")
+	}
+}
@@ -1,12 +1,10 @@
 package scripting
 
 import (
-	"reflect"
-	"strings"
+	"fmt"
 	"time"
 
 	"github.com/bedrock-tool/bedrocktool/handlers/worlds/entity"
-	"github.com/bedrock-tool/bedrocktool/utils"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/dop251/goja"
 	"github.com/go-gl/mathgl/mgl32"
@@ -15,12 +13,13 @@ import (
 )
 
 func (v *VM) OnEntityAdd(entity *entity.Entity, timeReceived time.Time) (apply bool) {
-	if v.CB.OnEntityAdd == nil {
+	cb := v.registered().OnEntityAdd
+	if cb == nil {
 		return true
 	}
 	apply = true
-	err := utils.RecoverCall(func() error {
-		applyV := v.CB.OnEntityAdd(entity, newEntityDataObject(v.runtime, entity.Metadata), float64(timeReceived.UnixMilli()))
+	err := v.run(func() error {
+		applyV := cb(entity, newEntityDataObject(v.runtime, entity.Metadata), float64(timeReceived.UnixMilli()))
 		if !goja.IsUndefined(applyV) {
 			apply = applyV.ToBoolean()
 		}
@@ -33,11 +32,12 @@ func (v *VM) OnEntityAdd(entity *entity.Entity, timeReceived time.Time) (apply b
 }
 
 func (v *VM) OnEntityDataUpdate(entity *entity.Entity, timeReceived time.Time) {
-	if v.CB.OnEntityDataUpdate == nil {
+	cb := v.registered().OnEntityDataUpdate
+	if cb == nil {
 		return
 	}
-	err := utils.RecoverCall(func() error {
-		v.CB.OnEntityDataUpdate(entity, newEntityDataObject(v.runtime, entity.Metadata), float64(timeReceived.UnixMilli()))
+	err := v.run(func() error {
+		cb(entity, newEntityDataObject(v.runtime, entity.Metadata), float64(timeReceived.UnixMilli()))
 		return nil
 	})
 	if err != nil {
@@ -46,11 +46,12 @@ func (v *VM) OnEntityDataUpdate(entity *entity.Entity, timeReceived time.Time) {
 }
 
 func (v *VM) OnChunkAdd(pos world.ChunkPos, timeReceived time.Time) (apply bool) {
-	if v.CB.OnChunkAdd == nil {
+	cb := v.registered().OnChunkAdd
+	if cb == nil {
 		return true
 	}
-	err := utils.RecoverCall(func() error {
-		applyV := v.CB.OnChunkAdd(pos, float64(timeReceived.UnixMilli()))
+	err := v.run(func() error {
+		applyV := cb(pos, float64(timeReceived.UnixMilli()))
 		if !goja.IsUndefined(applyV) {
 			apply = applyV.ToBoolean()
 		}
@@ -64,13 +65,14 @@ func (v *VM) OnChunkAdd(pos world.ChunkPos, timeReceived time.Time) (apply bool)
 }
 
 func (v *VM) OnBlockUpdate(name string, properties map[string]any, pos protocol.BlockPos, timeReceived time.Time) (apply bool) {
-	if v.CB.OnBlockUpdate == nil {
+	cb := v.registered().OnBlockUpdate
+	if cb == nil {
 		return true
 	}
 
 	apply = true
-	err := utils.RecoverCall(func() error {
-		applyV := v.CB.OnBlockUpdate(name, properties, pos, float64(timeReceived.UnixMilli()))
+	err := v.run(func() error {
+		applyV := cb(name, properties, pos, float64(timeReceived.UnixMilli()))
 		if !goja.IsUndefined(applyV) {
 			apply = applyV.ToBoolean()
 		}
@@ -85,12 +87,13 @@ func (v *VM) OnBlockUpdate(name string, properties map[string]any, pos protocol.
 }
 
 func (v *VM) OnSpawnParticle(name string, position mgl32.Vec3, timeReceived time.Time) {
-	if v.CB.OnSpawnParticle == nil {
+	cb := v.registered().OnSpawnParticle
+	if cb == nil {
 		return
 	}
 
-	err := utils.RecoverCall(func() error {
-		v.CB.OnSpawnParticle(name, position, float64(timeReceived.UnixMilli()))
+	err := v.run(func() error {
+		cb(name, position, float64(timeReceived.UnixMilli()))
 		return nil
 	})
 	if err != nil {
@@ -98,21 +101,141 @@ func (v *VM) OnSpawnParticle(name string, position mgl32.Vec3, timeReceived time
 	}
 }
 
-func (v *VM) OnPacket(pk packet.Packet, toServer bool, timeReceived time.Time) (drop bool) {
-	if v.CB.OnPacket == nil {
-		return false
+// OnPacket returns the packet to use instead of pk, the callback can return true to drop it
+// or a modified packet
+func (v *VM) OnPacket(pk packet.Packet, toServer bool, timeReceived time.Time) (packet.Packet, bool) {
+	cb := v.registered().OnPacket
+	if cb == nil {
+		return pk, false
 	}
 
-	v.lock.Lock()
-	defer v.lock.Unlock()
-	err := utils.RecoverCall(func() error {
-		packetName := strings.Split(reflect.TypeOf(pk).String(), ".")[1]
-		drop = v.CB.OnPacket(packetName, pk, toServer, float64(timeReceived.UnixMilli()))
+	name := packetName(pk)
+	out, drop := pk, false
+	err := v.run(func() error {
+		result := cb(name, pk, toServer, float64(timeReceived.UnixMilli()))
+		if result == nil || goja.IsUndefined(result) || goja.IsNull(result) {
+			return nil
+		}
+		if _, isObject := result.(*goja.Object); !isObject {
+			drop = result.ToBoolean()
+			return nil
+		}
+		modified, err := v.toPacket(name, result, toServer)
+		if err != nil {
+			return fmt.Errorf("Packet %w", err)
+		}
+		out = modified
 		return nil
 	})
 	if err != nil {
 		v.log.Error(err)
-		return false
+		return pk, false
+	}
+	return out, drop
+}
+
+// call runs a callback that returns nothing on the loop
+func (v *VM) call(fn func()) {
+	err := v.run(func() error {
+		fn()
+		return nil
+	})
+	if err != nil {
+		v.log.Error(err)
+	}
+}
+
+func (v *VM) OnText(pk *packet.Text, toServer bool, timeReceived time.Time) {
+	cb := v.registered().OnText
+	if cb == nil {
+		return
+	}
+	sender, message := ParseText(pk)
+	v.call(func() {
+		cb(sender, message, pk.TextType, toServer, float64(timeReceived.UnixMilli()))
+	})
+}
+
+func (v *VM) OnPlayerAdd(player Player, timeReceived time.Time) {
+	cb := v.registered().OnPlayerAdd
+	if cb == nil {
+		return
+	}
+	v.call(func() {
+		cb(player, float64(timeReceived.UnixMilli()))
+	})
+}
+
+func (v *VM) OnPlayerRemove(player Player, timeReceived time.Time) {
+	cb := v.registered().OnPlayerRemove
+	if cb == nil {
+		return
+	}
+	v.call(func() {
+		cb(player, float64(timeReceived.UnixMilli()))
+	})
+}
+
+func (v *VM) OnContainerOpen(container Container, items []ContainerItem, timeReceived time.Time) {
+	cb := v.registered().OnContainerOpen
+	if cb == nil {
+		return
 	}
-	return drop
+	v.call(func() {
+		cb(container, items, float64(timeReceived.UnixMilli()))
+	})
+}
+
+func (v *VM) OnChangeDimension(dimension int32, position mgl32.Vec3, timeReceived time.Time) {
+	cb := v.registered().OnChangeDimension
+	if cb == nil {
+		return
+	}
+	v.call(func() {
+		cb(dimension, position, float64(timeReceived.UnixMilli()))
+	})
+}
+
+func (v *VM) OnEntityRemove(entity *entity.Entity, timeReceived time.Time) {
+	cb := v.registered().OnEntityRemove
+	if cb == nil {
+		return
+	}
+	v.call(func() {
+		cb(entity, float64(timeReceived.UnixMilli()))
+	})
+}
+
+func (v *VM) OnWorldSaveStart(name string, chunks int) {
+	cb := v.registered().OnWorldSaveStart
+	if cb == nil {
+		return
+	}
+	v.call(func() {
+		cb(name, chunks, float64(time.Now().UnixMilli()))
+	})
+}
+
+func (v *VM) OnWorldSaveEnd(name, filename string, err error) {
+	cb := v.registered().OnWorldSaveEnd
+	if cb == nil {
+		return
+	}
+	var errString string
+	if err != nil {
+		errString = err.Error()
+	}
+	v.call(func() {
+		cb(name, filename, errString, float64(time.Now().UnixMilli()))
+	})
+}
+
+func (v *VM) OnDisconnect() {
+	cb := v.registered().OnDisconnect
+	if cb == nil {
+		return
+	}
+	v.call(func() {
+		cb(float64(time.Now().UnixMilli()))
+	})
 }
@@ -0,0 +1,100 @@
+package scripting
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// clientPackets are the packets the client sends, serverPackets the ones the server sends, by name
+var (
+	clientPackets = packetNames(packet.NewClientPool())
+	serverPackets = packetNames(packet.NewServerPool())
+)
+
+func packetNames(pool packet.Pool) map[string]func() packet.Packet {
+	names := make(map[string]func() packet.Packet, len(pool))
+	for _, fn := range pool {
+		names[packetName(fn())] = fn
+	}
+	return names
+}
+
+// packetName returns the name scripts use for a packet, the name of its type
+func packetName(pk packet.Packet) string {
+	return strings.Split(reflect.TypeOf(pk).String(), ".")[1]
+}
+
+// toPacket converts a packet from a script to the go packet, it can be a packet passed to the script
+// or an object with the fields of the packet. toServer selects if it is a packet the client or the server sends
+func (v *VM) toPacket(name string, value goja.Value, toServer bool) (packet.Packet, error) {
+	pool := serverPackets
+	if toServer {
+		pool = clientPackets
+	}
+	newPacket, ok := pool[name]
+	if !ok {
+		if toServer {
+			return nil, fmt.Errorf("%s is not a packet the client sends", name)
+		}
+		return nil, fmt.Errorf("%s is not a packet the server sends", name)
+	}
+
+	if goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil, fmt.Errorf("%s: missing packet fields", name)
+	}
+	if pk, ok := value.Export().(packet.Packet); ok {
+		if packetName(pk) != name {
+			return nil, fmt.Errorf("%s: got a %s packet", name, packetName(pk))
+		}
+		return pk, nil
+	}
+
+	pk := newPacket()
+	obj := value.ToObject(v.runtime)
+	t := reflect.TypeOf(pk).Elem()
+	for _, key := range obj.Keys() {
+		if _, ok := t.FieldByName(key); !ok {
+			return nil, fmt.Errorf("%s has no field %s", name, key)
+		}
+	}
+	if err := v.runtime.ExportTo(value, pk); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return pk, nil
+}
+
+// newProxyObject returns the proxy object scripts send packets with, errors are written to the script log
+func (v *VM) newProxyObject() *goja.Object {
+	obj := v.runtime.NewObject()
+	obj.Set("sendToClient", func(name string, fields goja.Value) bool {
+		pk, err := v.toPacket(name, fields, false)
+		if err == nil {
+			err = v.session.ClientWritePacket(pk)
+		}
+		if err != nil {
+			v.log.Errorf("sendToClient %s", err)
+			return false
+		}
+		return true
+	})
+	obj.Set("sendToServer", func(name string, fields goja.Value) bool {
+		pk, err := v.toPacket(name, fields, true)
+		if err == nil {
+			if v.session.Server == nil {
+				err = fmt.Errorf("%s: not connected to a server", name)
+			} else {
+				err = v.session.Server.WritePacket(pk)
+			}
+		}
+		if err != nil {
+			v.log.Errorf("sendToServer %s", err)
+			return false
+		}
+		return true
+	})
+	return obj
+}
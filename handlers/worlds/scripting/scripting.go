@@ -1,12 +1,16 @@
 package scripting
 
 import (
+	"errors"
+	"slices"
 	"sync"
 
 	"github.com/bedrock-tool/bedrocktool/handlers/worlds/entity"
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/proxy"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/dop251/goja"
-	"github.com/dop251/goja_nodejs/console"
+	"github.com/dop251/goja_nodejs/eventloop"
 	"github.com/dop251/goja_nodejs/require"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
@@ -17,57 +21,160 @@ import (
 )
 
 type VM struct {
+	// runtime must only be used on the loop
 	runtime *goja.Runtime
-	lock    sync.Mutex
+	loop    *eventloop.EventLoop
 	log     *logrus.Entry
+	session *proxy.Session
+	// loaded is set once the script ran, only used on the loop
+	loaded bool
 
-	CB struct {
-		OnEntityAdd        func(entity *entity.Entity, metadata *goja.Object, timeReceived float64) (apply goja.Value)
-		OnChunkAdd         func(pos world.ChunkPos, timeReceived float64) (apply goja.Value)
-		OnEntityDataUpdate func(entity *entity.Entity, metadata *goja.Object, timeReceived float64)
-		OnBlockUpdate      func(name string, properties map[string]any, pos protocol.BlockPos, timeReceived float64) (apply goja.Value)
-		OnSpawnParticle    func(name string, pos mgl32.Vec3, timeReceived float64)
-		OnPacket           func(name string, pk packet.Packet, toServer bool, timeReceived float64) (drop bool)
-	}
+	// cb are the callbacks the script registered, scripts can register them at any time from the loop
+	cb     callbacks
+	cbLock sync.RWMutex
+}
+
+type callbacks struct {
+	OnEntityAdd        func(entity *entity.Entity, metadata *goja.Object, timeReceived float64) (apply goja.Value)
+	OnChunkAdd         func(pos world.ChunkPos, timeReceived float64) (apply goja.Value)
+	OnEntityDataUpdate func(entity *entity.Entity, metadata *goja.Object, timeReceived float64)
+	OnBlockUpdate      func(name string, properties map[string]any, pos protocol.BlockPos, timeReceived float64) (apply goja.Value)
+	OnSpawnParticle    func(name string, pos mgl32.Vec3, timeReceived float64)
+	OnPacket           func(name string, pk packet.Packet, toServer bool, timeReceived float64) (result goja.Value)
+	OnText             func(sender, message string, textType byte, toServer bool, timeReceived float64)
+	OnPlayerAdd        func(player Player, timeReceived float64)
+	OnPlayerRemove     func(player Player, timeReceived float64)
+	OnContainerOpen    func(container Container, items []ContainerItem, timeReceived float64)
+	OnChangeDimension  func(dimension int32, pos mgl32.Vec3, timeReceived float64)
+	OnEntityRemove     func(entity *entity.Entity, timeReceived float64)
+	OnWorldSaveStart   func(name string, chunks int, time float64)
+	OnWorldSaveEnd     func(name, filename, err string, time float64)
+	OnDisconnect       func(time float64)
 }
 
-func New() *VM {
+// registered returns the callbacks the script has registered right now
+func (v *VM) registered() callbacks {
+	v.cbLock.RLock()
+	defer v.cbLock.RUnlock()
+	return v.cb
+}
+
+var errClosed = errors.New("script vm is closed")
+
+func New(session *proxy.Session) *VM {
 	v := &VM{
-		runtime: goja.New(),
+		loop:    eventloop.NewEventLoop(eventloop.WithRegistry(new(require.Registry))),
 		log:     logrus.WithField("part", "jsvm"),
+		session: session,
+	}
+	v.loop.Start()
+	// jobs run in the order they are added, so the runtime is set before anything uses it
+	v.loop.RunOnLoop(func(r *goja.Runtime) {
+		v.runtime = r
+	})
+	err := v.run(func() error {
+		v.setGlobals()
+		return nil
+	})
+	if err != nil {
+		v.log.Error(err)
+	}
+	return v
+}
+
+// run calls fn on the event loop and waits for it, so callbacks are never called at the same time
+// as timers, promises or other callbacks
+func (v *VM) run(fn func() error) error {
+	done := make(chan error, 1)
+	ok := v.loop.RunOnLoop(func(*goja.Runtime) {
+		done <- utils.RecoverCall(fn)
+	})
+	if !ok {
+		return errClosed
 	}
+	return <-done
+}
+
+// Close stops the event loop, timers that did not run yet are cancelled
+func (v *VM) Close() {
+	v.loop.Terminate()
+}
 
-	registry := new(require.Registry)
-	registry.Enable(v.runtime)
-	console.Enable(v.runtime)
+func (v *VM) setGlobals() {
+	// the event loop ignores errors in timer callbacks
+	for _, name := range []string{"setTimeout", "setInterval", "setImmediate"} {
+		schedule, ok := goja.AssertFunction(v.runtime.Get(name))
+		if !ok {
+			continue
+		}
+		v.runtime.Set(name, func(call goja.FunctionCall) goja.Value {
+			args := slices.Clone(call.Arguments)
+			if fn, ok := goja.AssertFunction(call.Argument(0)); ok {
+				args[0] = v.runtime.ToValue(func(call goja.FunctionCall) goja.Value {
+					if _, err := fn(call.This, call.Arguments...); err != nil {
+						v.log.Errorf("%s %s", name, err)
+					}
+					return goja.Undefined()
+				})
+			}
+			ret, err := schedule(goja.Undefined(), args...)
+			if err != nil {
+				panic(err)
+			}
+			return ret
+		})
+	}
 
 	events := v.runtime.NewObject()
 	events.Set("register", func(name string, callback goja.Value) (err error) {
+		v.cbLock.Lock()
+		defer v.cbLock.Unlock()
 		switch name {
 		case "EntityAdd":
-			err = v.runtime.ExportTo(callback, &v.CB.OnEntityAdd)
+			err = v.runtime.ExportTo(callback, &v.cb.OnEntityAdd)
 		case "EntityDataUpdate":
-			err = v.runtime.ExportTo(callback, &v.CB.OnEntityDataUpdate)
+			err = v.runtime.ExportTo(callback, &v.cb.OnEntityDataUpdate)
 		case "ChunkAdd":
-			err = v.runtime.ExportTo(callback, &v.CB.OnChunkAdd)
+			err = v.runtime.ExportTo(callback, &v.cb.OnChunkAdd)
 		case "BlockUpdate":
-			err = v.runtime.ExportTo(callback, &v.CB.OnBlockUpdate)
+			err = v.runtime.ExportTo(callback, &v.cb.OnBlockUpdate)
 		case "SpawnParticle":
-			err = v.runtime.ExportTo(callback, &v.CB.OnSpawnParticle)
+			err = v.runtime.ExportTo(callback, &v.cb.OnSpawnParticle)
 		case "Packet":
-			err = v.runtime.ExportTo(callback, &v.CB.OnPacket)
+			err = v.runtime.ExportTo(callback, &v.cb.OnPacket)
+		case "Text":
+			err = v.runtime.ExportTo(callback, &v.cb.OnText)
+		case "PlayerAdd":
+			err = v.runtime.ExportTo(callback, &v.cb.OnPlayerAdd)
+		case "PlayerRemove":
+			err = v.runtime.ExportTo(callback, &v.cb.OnPlayerRemove)
+		case "ContainerOpen":
+			err = v.runtime.ExportTo(callback, &v.cb.OnContainerOpen)
+		case "ChangeDimension":
+			err = v.runtime.ExportTo(callback, &v.cb.OnChangeDimension)
+		case "EntityRemove":
+			err = v.runtime.ExportTo(callback, &v.cb.OnEntityRemove)
+		case "WorldSaveStart":
+			err = v.runtime.ExportTo(callback, &v.cb.OnWorldSaveStart)
+		case "WorldSaveEnd":
+			err = v.runtime.ExportTo(callback, &v.cb.OnWorldSaveEnd)
+		case "Disconnect":
+			err = v.runtime.ExportTo(callback, &v.cb.OnDisconnect)
 		}
 		return err
 	})
 	v.runtime.GlobalObject().Set("events", events)
+	v.runtime.GlobalObject().Set("proxy", v.newProxyObject())
 
-	return v
+	commands := v.runtime.NewObject()
+	commands.Set("register", v.registerCommand)
+	v.runtime.GlobalObject().Set("commands", commands)
 }
 
 func (v *VM) Load(script string) error {
-	_, err := v.runtime.RunScript("script.js", script)
-	if err != nil {
+	return v.run(func() error {
+		_, err := v.runtime.RunScript("script.js", script)
+		v.loaded = true
 		return err
-	}
-	return nil
+	})
 }
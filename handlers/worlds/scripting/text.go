@@ -0,0 +1,42 @@
+package scripting
+
+import (
+	"regexp"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// chatFormats are the ways servers commonly format chat in raw messages,
+// ranks in brackets before the name are skipped
+var chatFormats = []*regexp.Regexp{
+	regexp.MustCompile(`^<([^>]+)> (.*)$`),
+	regexp.MustCompile(`^(?:\[[^\]]*\] ?)*([^\s:\[\]]+) ?[:»>] (.*)$`),
+}
+
+// ParseText returns who sent a text and the message without the sender,
+// sender is empty for messages from the server. Chat that servers send as raw text is matched
+// against chatFormats
+func ParseText(pk *packet.Text) (sender, message string) {
+	switch pk.TextType {
+	case packet.TextTypeChat, packet.TextTypeWhisper, packet.TextTypeAnnouncement:
+		if pk.SourceName != "" {
+			return text.Clean(pk.SourceName), pk.Message
+		}
+	case packet.TextTypeTranslation:
+		if pk.Message == "chat.type.text" && len(pk.Parameters) == 2 {
+			return text.Clean(pk.Parameters[0]), pk.Parameters[1]
+		}
+	}
+	if pk.TextType != packet.TextTypeRaw && pk.TextType != packet.TextTypeChat {
+		return "", pk.Message
+	}
+
+	clean := text.Clean(pk.Message)
+	for _, format := range chatFormats {
+		if m := format.FindStringSubmatch(clean); m != nil {
+			return m[1], m[2]
+		}
+	}
+	return "", pk.Message
+}
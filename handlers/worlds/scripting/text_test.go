@@ -0,0 +1,26 @@
+package scripting
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestParseText(t *testing.T) {
+	for _, tt := range []struct {
+		pk              packet.Text
+		sender, message string
+	}{
+		{packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "hi"}, "Steve", "hi"},
+		{packet.Text{TextType: packet.TextTypeTranslation, Message: "chat.type.text", Parameters: []string{"Alex", "hello"}}, "Alex", "hello"},
+		{packet.Text{TextType: packet.TextTypeRaw, Message: "§7[§bVIP§7] §fNotch: ok"}, "Notch", "ok"},
+		{packet.Text{TextType: packet.TextTypeRaw, Message: "§e<Herobrine>§r boo"}, "Herobrine", "boo"},
+		{packet.Text{TextType: packet.TextTypeRaw, Message: "Notch » hey there"}, "Notch", "hey there"},
+		{packet.Text{TextType: packet.TextTypeSystem, Message: "Welcome to the server"}, "", "Welcome to the server"},
+	} {
+		sender, message := ParseText(&tt.pk)
+		if sender != tt.sender || message != tt.message {
+			t.Errorf("%q: got %q %q, want %q %q", tt.pk.Message, sender, message, tt.sender, tt.message)
+		}
+	}
+}
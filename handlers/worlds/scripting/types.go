@@ -0,0 +1,30 @@
+package scripting
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// Player is an entry of the player list
+type Player struct {
+	UUID           string
+	Username       string
+	XUID           string
+	EntityUniqueID int64
+}
+
+// Container is a container window the player opened
+type Container struct {
+	WindowID       byte
+	ContainerType  byte
+	Position       protocol.BlockPos
+	EntityUniqueID int64
+}
+
+// ContainerItem is an item in a container with its resolved name
+type ContainerItem struct {
+	Slot     int
+	Name     string
+	Metadata int
+	Count    int
+	NBT      map[string]any
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -49,6 +50,11 @@ type WorldSettings struct {
 	Script          string
 	Players         bool
 	BlockUpdates    bool
+	ExtractText     bool
+
+	// level.dat values to use instead of the ones from the server
+	LevelOverrides worldstate.LevelSettings
+	SpawnAtPlayer  bool
 }
 
 type serverState struct {
@@ -68,9 +74,24 @@ type serverState struct {
 	customBlocks       []protocol.BlockEntry
 	openItemContainers map[byte]*itemContainer
 	playerInventory    []protocol.ItemInstance
+	playerArmour       []protocol.ItemInstance
+	playerOffhand      []protocol.ItemInstance
+	playerEnderChest   []protocol.ItemInstance
+	selectedSlot       byte
+	playerLevel        int32
+	playerXPProgress   float32
+	playerGameMode     int32
 	dimensions         map[int]protocol.DimensionDefinition
 	playerSkins        map[uuid.UUID]*protocol.Skin
+	playerNames        map[int64]string
+	playerList         map[uuid.UUID]scripting.Player
+	playerUniqueID     int64
 	entityProperties   map[string][]entity.EntityProperty
+	scoreboard         *worldstate.Scoreboard
+	level              worldstate.LevelSettings
+	worldSpawns        map[int]cube.Pos
+	// client entities defined in the readable server packs
+	clientEntities map[string]bool
 }
 
 type worldsHandler struct {
@@ -93,6 +114,8 @@ type worldsHandler struct {
 type itemContainer struct {
 	OpenPacket *packet.ContainerOpen
 	Content    *packet.InventoryContent
+	// scriptNotified is set once the ContainerOpen script event ran with the content
+	scriptNotified bool
 }
 
 func NewWorldsHandler(ctx context.Context, settings WorldSettings) func() *proxy.Handler {
@@ -124,8 +147,12 @@ func NewWorldsHandler(ctx context.Context, settings WorldSettings) func() *proxy
 
 			PacketCallback: w.packetHandler,
 			OnSessionEnd: func(s *proxy.Session) {
+				w.scripting.OnDisconnect()
 				w.SaveAndReset(true, nil)
 				w.wg.Wait()
+				w.mapUI.Stop()
+				w.mapUI.CloseCache()
+				w.scripting.Close()
 			},
 		}
 	}
@@ -139,14 +166,18 @@ func (w *worldsHandler) onSessionStart(session *proxy.Session, serverName string
 		openItemContainers: make(map[byte]*itemContainer),
 		dimensions:         make(map[int]protocol.DimensionDefinition),
 		playerSkins:        make(map[uuid.UUID]*protocol.Skin),
+		playerNames:        make(map[int64]string),
+		playerList:         make(map[uuid.UUID]scripting.Player),
 		biomes:             world.DefaultBiomes.Clone(),
 		entityProperties:   make(map[string][]entity.EntityProperty),
 		behaviorPack:       behaviourpack.New(serverName),
 		resourcePack:       resourcepack.New(),
+		scoreboard:         worldstate.NewScoreboard(),
+		worldSpawns:        make(map[int]cube.Pos),
 	}
 
 	w.mapUI = NewMapUI(w)
-	w.scripting = scripting.New()
+	w.scripting = scripting.New(session)
 
 	session.AddCommand(func(cmdline []string) bool {
 		return w.setWorldName(strings.Join(cmdline, " "))
@@ -198,6 +229,32 @@ func (w *worldsHandler) onSessionStart(session *proxy.Session, serverName string
 		Description: "start capturing entities, chunks",
 	})
 
+	session.AddCommand(func(args []string) bool {
+		if len(args) < 1 {
+			session.SendMessage("usage: map-mode <" + strings.Join(utils.RenderModeNames(), "|") + "> [y]")
+			return false
+		}
+		mode, err := utils.ParseRenderMode(args[0])
+		if err != nil {
+			session.SendMessage(err.Error())
+			return false
+		}
+		sliceY := int16(math.Floor(float64(session.Player.Position.Y())))
+		if len(args) > 1 {
+			y, err := strconv.Atoi(args[1])
+			if err != nil {
+				session.SendMessage(err.Error())
+				return false
+			}
+			sliceY = int16(y)
+		}
+		w.setRenderMode(mode, sliceY)
+		return true
+	}, protocol.Command{
+		Name:        "map-mode",
+		Description: "change how the map is drawn, the cave mode starts at y or the players height",
+	})
+
 	session.AddCommand(func(args []string) bool {
 		w.SaveAndReset(false, nil)
 		return true
@@ -220,6 +277,7 @@ func (w *worldsHandler) onSessionStart(session *proxy.Session, serverName string
 	if w.settings.Script != "" {
 		err := w.scripting.Load(w.settings.Script)
 		if err != nil {
+			w.scripting.Close()
 			return err
 		}
 	}
@@ -348,6 +406,25 @@ func (w *worldsHandler) setVoidGen(val bool) bool {
 	return true
 }
 
+func (w *worldsHandler) setRenderMode(mode utils.RenderMode, sliceY int16) {
+	w.mapUI.SetRenderMode(mode, sliceY)
+	w.currentWorld(func(world *worldstate.World) {
+		if err := world.RedrawChunks(); err != nil {
+			w.log.Error(err)
+		}
+	})
+	w.session.SendMessage(locale.Loc("map_mode", locale.Strmap{"Mode": mode.String()}))
+
+	messages.Router.Handle(&messages.Message{
+		Source: "subcommand",
+		Target: "ui",
+		Data: messages.SetValue{
+			Name:  "renderMode",
+			Value: mode.String(),
+		},
+	})
+}
+
 func (w *worldsHandler) setWorldName(val string) bool {
 	err := w.renameWorldState(val)
 	if err != nil {
@@ -388,10 +465,14 @@ func (w *worldsHandler) SaveAndReset(end bool, dim world.Dimension) {
 			f.Close()
 		}
 
+		worldState.Scoreboard = w.serverState.scoreboard.Save(w.serverState.playerUniqueID, w.serverState.playerNames)
+		worldState.Level = w.levelSettings(worldState.Dimension())
+
 		// reset map, increase counter for
 		w.serverState.worldCounter += 1
 		w.mapUI.Reset()
 
+		w.scripting.OnWorldSaveStart(worldState.Name, len(worldState.StoredChunks))
 		w.wg.Add(1)
 		go func() {
 			defer w.wg.Done()
@@ -399,6 +480,7 @@ func (w *worldsHandler) SaveAndReset(end bool, dim world.Dimension) {
 			if err != nil {
 				w.log.Error(err)
 			}
+			w.scripting.OnWorldSaveEnd(worldState.Name, worldState.Folder+".mcworld", err)
 		}()
 	}
 
@@ -437,6 +519,20 @@ func (w *worldsHandler) saveWorldState(worldState *worldstate.World) error {
 		return err
 	}
 
+	if playerPath := worldState.PlayerPath(); len(playerPath.Points) > 0 {
+		err = playerPath.Save(worldState.Folder + ".path")
+		if err != nil {
+			w.log.WithField("func", "saveWorldState").Error(err)
+		}
+	}
+
+	if w.settings.ExtractText {
+		err = worldState.ExportText(worldState.Folder + ".text")
+		if err != nil {
+			w.log.WithField("func", "saveWorldState").Error(err)
+		}
+	}
+
 	err = worldState.FinalizePacks(func(fs utils.WriterFS) (*resource.Header, error) {
 		if w.serverState.behaviorPack.HasContent() {
 			packFolder := path.Join("behavior_packs", utils.FormatPackName(w.serverState.serverName))
@@ -453,6 +549,21 @@ func (w *worldsHandler) saveWorldState(worldState *worldstate.World) error {
 			return &w.serverState.behaviorPack.Manifest.Header, nil
 		}
 		return nil, nil
+	}, func(fs utils.WriterFS) (*resource.Header, error) {
+		entityPack := resourcepack.New()
+		entityPack.Manifest.Header.Name = "placeholder entities"
+		for _, entityType := range w.resolveClientEntities(entityPack) {
+			w.log.Warnf("No client entity for %s in the server packs, adding a placeholder", entityType)
+		}
+		if !entityPack.HasContent() {
+			return nil, nil
+		}
+		packFolder := path.Join("resource_packs", utils.FormatPackName(w.serverState.serverName)+"_entities")
+		err := entityPack.WriteToDir(fs, packFolder)
+		if err != nil {
+			return nil, err
+		}
+		return &entityPack.Manifest.Header, nil
 	})
 	if err != nil {
 		return err
@@ -519,6 +630,8 @@ func (w *worldsHandler) openWorldState(deferred bool) {
 	w.worldState.BlockRegistry = w.serverState.blocks
 	w.worldState.ResourcePacks = w.session.Server.ResourcePacks()
 	w.worldState.UseHashedRids = w.serverState.useHashedRids
+	dim, _ := world.DimensionID(w.worldState.Dimension())
+	w.mapUI.OpenCache(utils.RenderCacheFolder(folder), dim)
 	w.worldState.Open(name, folder, deferred)
 }
 
@@ -531,3 +644,43 @@ func (w *worldsHandler) renameWorldState(name string) error {
 	})
 	return err
 }
+
+// levelSettings returns what the server sent for level.dat with the overrides applied
+func (w *worldsHandler) levelSettings(dim world.Dimension) worldstate.LevelSettings {
+	level := w.serverState.level
+	dimID, _ := world.DimensionID(dim)
+	if spawn, ok := w.serverState.worldSpawns[dimID]; ok {
+		level.Spawn = &spawn
+	}
+	level.Apply(w.settings.LevelOverrides)
+	if w.settings.SpawnAtPlayer {
+		level.Spawn = nil
+	}
+	return level
+}
+
+// resolveClientEntities adds a placeholder client entity to placeholders for every custom entity
+// that has no definition in the server resource packs that get copied into the world
+func (w *worldsHandler) resolveClientEntities(placeholders *resourcepack.Pack) (unresolved []string) {
+	if w.serverState.clientEntities == nil {
+		w.serverState.clientEntities = make(map[string]bool)
+		for _, pack := range w.session.Server.ResourcePacks() {
+			if pack.Encrypted() && !pack.CanRead() {
+				continue
+			}
+			for identifier := range resourcepack.FindClientEntities(pack) {
+				w.serverState.clientEntities[identifier] = true
+			}
+		}
+	}
+
+	for _, entityType := range w.serverState.behaviorPack.EntityTypes() {
+		if w.serverState.clientEntities[entityType] {
+			continue
+		}
+		width, height := w.serverState.behaviorPack.EntitySize(entityType)
+		placeholders.AddPlaceholderEntity(entityType, width, height)
+		unresolved = append(unresolved, entityType)
+	}
+	return unresolved
+}
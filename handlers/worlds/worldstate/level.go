@@ -0,0 +1,105 @@
+package worldstate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+)
+
+// LevelSettings are level.dat values sent by the server, nil fields are left at their defaults
+type LevelSettings struct {
+	Spawn          *cube.Pos
+	RainLevel      *float32
+	LightningLevel *float32
+	Difficulty     *int32
+	GameType       *int32
+}
+
+// Apply replaces the values in l with the ones that are set in o
+func (l *LevelSettings) Apply(o LevelSettings) {
+	if o.Spawn != nil {
+		l.Spawn = o.Spawn
+	}
+	if o.RainLevel != nil {
+		l.RainLevel = o.RainLevel
+	}
+	if o.LightningLevel != nil {
+		l.LightningLevel = o.LightningLevel
+	}
+	if o.Difficulty != nil {
+		l.Difficulty = o.Difficulty
+	}
+	if o.GameType != nil {
+		l.GameType = o.GameType
+	}
+}
+
+var weatherNames = map[string][2]float32{
+	"clear":   {0, 0},
+	"rain":    {1, 0},
+	"thunder": {1, 1},
+}
+
+var difficultyNames = map[string]int32{
+	"peaceful": 0,
+	"easy":     1,
+	"normal":   2,
+	"hard":     3,
+}
+
+var gameTypeNames = map[string]int32{
+	"survival":  0,
+	"creative":  1,
+	"adventure": 2,
+	"spectator": 6,
+}
+
+// ParseLevelOverrides parses the override flags, empty strings keep what the server sent.
+// spawn is either x,y,z or "player" to use the players position.
+func ParseLevelOverrides(spawn, weather, difficulty, gameType string) (o LevelSettings, spawnAtPlayer bool, err error) {
+	switch spawn {
+	case "":
+	case "player":
+		spawnAtPlayer = true
+	default:
+		parts := strings.Split(spawn, ",")
+		if len(parts) != 3 {
+			return o, false, fmt.Errorf("invalid spawn %q, expected x,y,z", spawn)
+		}
+		var pos cube.Pos
+		for i, p := range parts {
+			pos[i], err = strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				return o, false, fmt.Errorf("invalid spawn %q: %w", spawn, err)
+			}
+		}
+		o.Spawn = &pos
+	}
+
+	if weather != "" {
+		levels, ok := weatherNames[weather]
+		if !ok {
+			return o, false, fmt.Errorf("unknown weather %q", weather)
+		}
+		o.RainLevel, o.LightningLevel = &levels[0], &levels[1]
+	}
+
+	if difficulty != "" {
+		d, ok := difficultyNames[difficulty]
+		if !ok {
+			return o, false, fmt.Errorf("unknown difficulty %q", difficulty)
+		}
+		o.Difficulty = &d
+	}
+
+	if gameType != "" {
+		g, ok := gameTypeNames[gameType]
+		if !ok {
+			return o, false, fmt.Errorf("unknown game mode %q", gameType)
+		}
+		o.GameType = &g
+	}
+	return o, spawnAtPlayer, nil
+}
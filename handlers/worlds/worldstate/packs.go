@@ -79,7 +79,7 @@ func (w *World) addResourcePacks() error {
 	return nil
 }
 
-func (w *World) FinalizePacks(addBehaviorPack func(fs utils.WriterFS) (*resource.Header, error)) error {
+func (w *World) FinalizePacks(addBehaviorPack, addResourcePack func(fs utils.WriterFS) (*resource.Header, error)) error {
 	err := <-w.resourcePacksDone
 	if err != nil {
 		return err
@@ -110,6 +110,17 @@ func (w *World) FinalizePacks(addBehaviorPack func(fs utils.WriterFS) (*resource
 		}
 	}
 
+	header, err = addResourcePack(fs)
+	if err != nil {
+		return err
+	}
+	if header != nil {
+		w.resourcePackDependencies = append(w.resourcePackDependencies, resourcePackDependency{
+			UUID:    header.UUID.String(),
+			Version: header.Version,
+		})
+	}
+
 	if len(w.resourcePackDependencies) > 0 {
 		err := addPacksJSON(fs, "world_resource_packs.json", w.resourcePackDependencies)
 		if err != nil {
@@ -0,0 +1,215 @@
+package worldstate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// minimum distance in blocks the player has to move before a new point is recorded
+const pathMinDistance = 1
+
+// PathPoint is one recorded position of the local player
+type PathPoint struct {
+	Time      time.Time
+	Dimension int
+	Position  mgl32.Vec3
+}
+
+// PlayerPath is the path the local player took while a world was being captured
+type PlayerPath struct {
+	Points []PathPoint
+}
+
+// Add records a new point, returns false if the player hasnt moved far enough since the last one
+func (p *PlayerPath) Add(t time.Time, dimension int, pos mgl32.Vec3) bool {
+	if len(p.Points) > 0 {
+		last := p.Points[len(p.Points)-1]
+		if last.Dimension == dimension && last.Position.Sub(pos).Len() < pathMinDistance {
+			return false
+		}
+	}
+	p.Points = append(p.Points, PathPoint{
+		Time:      t,
+		Dimension: dimension,
+		Position:  pos,
+	})
+	return true
+}
+
+// Segments splits the path into parts that stay in one dimension
+func (p *PlayerPath) Segments() [][]PathPoint {
+	var segments [][]PathPoint
+	start := 0
+	for i := 1; i <= len(p.Points); i++ {
+		if i == len(p.Points) || p.Points[i].Dimension != p.Points[start].Dimension {
+			segments = append(segments, p.Points[start:i])
+			start = i
+		}
+	}
+	return segments
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   geoJSONLineString `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+type geoJSONLineString struct {
+	Type        string       `json:"type"`
+	Coordinates [][3]float32 `json:"coordinates"`
+}
+
+type geoJSONProperties struct {
+	Dimension int     `json:"dimension"`
+	Times     []int64 `json:"times"`
+}
+
+// WriteGeoJSON writes the path as a FeatureCollection with one LineString per dimension segment,
+// coordinates are [x, z, y] so that the x/z plane maps onto the map plane.
+func (p *PlayerPath) WriteGeoJSON(w io.Writer) error {
+	fc := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+	for _, segment := range p.Segments() {
+		feature := geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONLineString{
+				Type: "LineString",
+			},
+			Properties: geoJSONProperties{
+				Dimension: segment[0].Dimension,
+			},
+		}
+		for _, point := range segment {
+			feature.Geometry.Coordinates = append(feature.Geometry.Coordinates, [3]float32{
+				point.Position.X(), point.Position.Z(), point.Position.Y(),
+			})
+			feature.Properties.Times = append(feature.Properties.Times, point.Time.UnixMilli())
+		}
+		fc.Features = append(fc.Features, feature)
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(fc)
+}
+
+// WriteCSV writes the path as csv with one point per row
+func (p *PlayerPath) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "dimension", "x", "y", "z"}); err != nil {
+		return err
+	}
+	for _, point := range p.Points {
+		err := cw.Write([]string{
+			point.Time.Format(time.RFC3339Nano),
+			strconv.Itoa(point.Dimension),
+			strconv.FormatFloat(float64(point.Position.X()), 'f', 2, 32),
+			strconv.FormatFloat(float64(point.Position.Y()), 'f', 2, 32),
+			strconv.FormatFloat(float64(point.Position.Z()), 'f', 2, 32),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Save writes basename.geojson and basename.csv
+func (p *PlayerPath) Save(basename string) error {
+	for _, format := range []struct {
+		ext   string
+		write func(io.Writer) error
+	}{
+		{".geojson", p.WriteGeoJSON},
+		{".csv", p.WriteCSV},
+	} {
+		f, err := os.Create(basename + format.ext)
+		if err != nil {
+			return err
+		}
+		err = format.write(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPlayerPath reads a path written by Save, the format is picked by the file extension
+func ReadPlayerPath(filename string) (*PlayerPath, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var p PlayerPath
+	switch filepath.Ext(filename) {
+	case ".geojson", ".json":
+		var fc geoJSONFeatureCollection
+		if err := json.NewDecoder(f).Decode(&fc); err != nil {
+			return nil, err
+		}
+		for _, feature := range fc.Features {
+			for i, c := range feature.Geometry.Coordinates {
+				var t time.Time
+				if i < len(feature.Properties.Times) {
+					t = time.UnixMilli(feature.Properties.Times[i])
+				}
+				p.Points = append(p.Points, PathPoint{
+					Time:      t,
+					Dimension: feature.Properties.Dimension,
+					Position:  mgl32.Vec3{c[0], c[2], c[1]},
+				})
+			}
+		}
+	case ".csv":
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		for i, record := range records {
+			if i == 0 || len(record) < 5 {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339Nano, record[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			var point = PathPoint{Time: t}
+			point.Dimension, err = strconv.Atoi(record[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			for j := range 3 {
+				v, err := strconv.ParseFloat(record[2+j], 32)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", i+1, err)
+				}
+				point.Position[j] = float32(v)
+			}
+			p.Points = append(p.Points, point)
+		}
+	default:
+		return nil, errors.New("unknown path format " + filename)
+	}
+	return &p, nil
+}
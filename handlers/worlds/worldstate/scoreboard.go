@@ -0,0 +1,234 @@
+package worldstate
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// identity types as stored in the scoreboard key, they match the network values
+const (
+	scoreboardIdentityPlayer     = protocol.ScoreboardIdentityPlayer
+	scoreboardIdentityEntity     = protocol.ScoreboardIdentityEntity
+	scoreboardIdentityFakePlayer = protocol.ScoreboardIdentityFakePlayer
+)
+
+type scoreboardObjective struct {
+	DisplayName string
+	Criteria    string
+	// scoreboard id -> score
+	Scores map[int64]int32
+}
+
+type scoreboardDisplay struct {
+	ObjectiveName string
+	SortOrder     int32
+}
+
+type scoreboardIdentity struct {
+	Type           byte
+	EntityUniqueID int64
+	FakePlayerName string
+}
+
+// Scoreboard keeps track of the objectives and scores the server sends
+type Scoreboard struct {
+	objectives   map[string]*scoreboardObjective
+	displays     map[string]scoreboardDisplay
+	identities   map[int64]scoreboardIdentity
+	lastUniqueID int64
+}
+
+func NewScoreboard() *Scoreboard {
+	return &Scoreboard{
+		objectives: make(map[string]*scoreboardObjective),
+		displays:   make(map[string]scoreboardDisplay),
+		identities: make(map[int64]scoreboardIdentity),
+	}
+}
+
+func (s *Scoreboard) objective(name string) *scoreboardObjective {
+	o, ok := s.objectives[name]
+	if !ok {
+		o = &scoreboardObjective{
+			DisplayName: name,
+			Criteria:    "dummy",
+			Scores:      make(map[int64]int32),
+		}
+		s.objectives[name] = o
+	}
+	return o
+}
+
+func (s *Scoreboard) SetDisplayObjective(pk *packet.SetDisplayObjective) {
+	if pk.ObjectiveName == "" {
+		delete(s.displays, pk.DisplaySlot)
+		return
+	}
+	o := s.objective(pk.ObjectiveName)
+	o.DisplayName = pk.DisplayName
+	if pk.CriteriaName != "" {
+		o.Criteria = pk.CriteriaName
+	}
+	s.displays[pk.DisplaySlot] = scoreboardDisplay{
+		ObjectiveName: pk.ObjectiveName,
+		SortOrder:     pk.SortOrder,
+	}
+}
+
+func (s *Scoreboard) RemoveObjective(pk *packet.RemoveObjective) {
+	delete(s.objectives, pk.ObjectiveName)
+	maps.DeleteFunc(s.displays, func(_ string, d scoreboardDisplay) bool {
+		return d.ObjectiveName == pk.ObjectiveName
+	})
+}
+
+func (s *Scoreboard) SetScore(pk *packet.SetScore) {
+	for _, entry := range pk.Entries {
+		s.lastUniqueID = max(s.lastUniqueID, entry.EntryID)
+		switch pk.ActionType {
+		case packet.ScoreboardActionModify:
+			s.objective(entry.ObjectiveName).Scores[entry.EntryID] = entry.Score
+			s.identities[entry.EntryID] = scoreboardIdentity{
+				Type:           entry.IdentityType,
+				EntityUniqueID: entry.EntityUniqueID,
+				FakePlayerName: entry.DisplayName,
+			}
+		case packet.ScoreboardActionRemove:
+			if o, ok := s.objectives[entry.ObjectiveName]; ok {
+				delete(o.Scores, entry.EntryID)
+			}
+		}
+	}
+}
+
+func (s *Scoreboard) SetScoreboardIdentity(pk *packet.SetScoreboardIdentity) {
+	for _, entry := range pk.Entries {
+		s.lastUniqueID = max(s.lastUniqueID, entry.EntryID)
+		switch pk.ActionType {
+		case packet.ScoreboardIdentityActionRegister:
+			s.identities[entry.EntryID] = scoreboardIdentity{
+				Type:           scoreboardIdentityPlayer,
+				EntityUniqueID: entry.EntityUniqueID,
+			}
+		case packet.ScoreboardIdentityActionClear:
+			delete(s.identities, entry.EntryID)
+		}
+	}
+}
+
+type scoreboardScoreNBT struct {
+	Score        int32 `nbt:"Score"`
+	ScoreboardId int64 `nbt:"ScoreboardId"`
+}
+
+type scoreboardObjectiveNBT struct {
+	Name        string               `nbt:"Name"`
+	DisplayName string               `nbt:"DisplayName"`
+	Criteria    string               `nbt:"Criteria"`
+	Scores      []scoreboardScoreNBT `nbt:"Scores"`
+}
+
+type scoreboardDisplayNBT struct {
+	Name          string `nbt:"Name"`
+	ObjectiveName string `nbt:"ObjectiveName"`
+	SortOrder     uint8  `nbt:"SortOrder"`
+}
+
+// ScoreboardData is the content of the scoreboard key in the world db
+type ScoreboardData struct {
+	Criteria          []any                    `nbt:"Criteria"`
+	DisplayObjectives []scoreboardDisplayNBT   `nbt:"DisplayObjectives"`
+	Entries           []map[string]any         `nbt:"Entries"`
+	LastUniqueID      int64                    `nbt:"LastUniqueID"`
+	Objectives        []scoreboardObjectiveNBT `nbt:"Objectives"`
+}
+
+// Save converts the tracked state to the format bedrock stores in the world.
+// The saved world has no player records for other players, so player identities are written as
+// fake players with their name, only the local player keeps a real player identity.
+func (s *Scoreboard) Save(localPlayerID int64, playerNames map[int64]string) *ScoreboardData {
+	if len(s.objectives) == 0 {
+		return nil
+	}
+
+	data := &ScoreboardData{
+		Criteria:     []any{},
+		LastUniqueID: s.lastUniqueID,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.displays)) {
+		d := s.displays[name]
+		if _, ok := s.objectives[d.ObjectiveName]; !ok {
+			continue
+		}
+		data.DisplayObjectives = append(data.DisplayObjectives, scoreboardDisplayNBT{
+			Name:          name,
+			ObjectiveName: d.ObjectiveName,
+			SortOrder:     uint8(d.SortOrder),
+		})
+	}
+
+	used := make(map[int64]bool)
+	for _, name := range slices.Sorted(maps.Keys(s.objectives)) {
+		o := s.objectives[name]
+		obj := scoreboardObjectiveNBT{
+			Name:        name,
+			DisplayName: o.DisplayName,
+			Criteria:    o.Criteria,
+			Scores:      []scoreboardScoreNBT{},
+		}
+		for id, score := range o.Scores {
+			if _, ok := s.identities[id]; !ok {
+				continue
+			}
+			used[id] = true
+			obj.Scores = append(obj.Scores, scoreboardScoreNBT{
+				Score:        score,
+				ScoreboardId: id,
+			})
+		}
+		slices.SortFunc(obj.Scores, func(a, b scoreboardScoreNBT) int {
+			return cmp.Compare(a.ScoreboardId, b.ScoreboardId)
+		})
+		data.Objectives = append(data.Objectives, obj)
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(used)) {
+		identity := s.identities[id]
+		entry := map[string]any{
+			"IdentityType": identity.Type,
+			"ScoreboardId": id,
+		}
+		switch identity.Type {
+		case scoreboardIdentityPlayer:
+			if identity.EntityUniqueID == localPlayerID {
+				entry["PlayerId"] = identity.EntityUniqueID
+				break
+			}
+			name, ok := playerNames[identity.EntityUniqueID]
+			if !ok {
+				name = fmt.Sprintf("player_%d", identity.EntityUniqueID)
+			}
+			entry["IdentityType"] = byte(scoreboardIdentityFakePlayer)
+			entry["FakePlayerName"] = name
+		case scoreboardIdentityEntity:
+			entry["EntityID"] = identity.EntityUniqueID
+		case scoreboardIdentityFakePlayer:
+			entry["FakePlayerName"] = identity.FakePlayerName
+		}
+		data.Entries = append(data.Entries, entry)
+	}
+	if data.DisplayObjectives == nil {
+		data.DisplayObjectives = []scoreboardDisplayNBT{}
+	}
+	if data.Entries == nil {
+		data.Entries = []map[string]any{}
+	}
+
+	return data
+}
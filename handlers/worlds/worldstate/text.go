@@ -0,0 +1,42 @@
+package worldstate
+
+import (
+	"log/slog"
+
+	"github.com/bedrock-tool/bedrocktool/utils/worldtext"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/df-mc/goleveldb/leveldb/opt"
+)
+
+// ExportText reopens the finished world and writes the text of all signs, lecterns and books
+// to basename.json and basename.csv
+func (w *World) ExportText(basename string) error {
+	db, err := mcdb.Config{
+		Log:    slog.Default(),
+		Blocks: w.BlockRegistry,
+		LDBOptions: &opt.Options{
+			ReadOnly: true,
+		},
+	}.Open(w.Folder)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	entries, err := worldtext.FromDB(db, w.BlockRegistry)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	for _, ext := range []string{".json", ".csv"} {
+		err = worldtext.WriteFile(basename+ext, entries)
+		if err != nil {
+			return err
+		}
+	}
+	w.log.Infof("Exported %d texts", len(entries))
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/df-mc/dragonfly/server/world/mcdb"
 	"github.com/df-mc/goleveldb/leveldb"
 	"github.com/df-mc/goleveldb/leveldb/opt"
+	"github.com/go-gl/mathgl/mgl32"
 	"github.com/google/uuid"
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
@@ -73,7 +74,10 @@ type World struct {
 	resourcePacksDone        chan error
 	resourcePackDependencies []resourcePackDependency
 
-	players map[uuid.UUID]*player
+	players    map[uuid.UUID]*player
+	playerPath PlayerPath
+	Scoreboard *ScoreboardData
+	Level      LevelSettings
 
 	VoidGen  bool
 	timeSync time.Time
@@ -222,6 +226,19 @@ func (w *World) Range() cube.Range {
 	return w.dimRange
 }
 
+// RecordPlayerPosition adds the local players position to the recorded path
+func (w *World) RecordPlayerPosition(t time.Time, pos mgl32.Vec3) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+	dimID, _ := world.DimensionID(w.dimension)
+	w.playerPath.Add(t, dimID, pos)
+}
+
+// PlayerPath returns the path the local player took in this world
+func (w *World) PlayerPath() *PlayerPath {
+	return &w.playerPath
+}
+
 func (w *World) SetTime(real time.Time, ingame int) {
 	w.timeSync = real
 	w.time = ingame
@@ -336,6 +353,20 @@ func (w *World) SetBlockNBT(pos cube.Pos, nbt map[string]any, merge bool) error
 	return w.storeChunkLocked(chunkPos, ch)
 }
 
+// BlockAt returns the block at pos if the chunk it is in has been received
+func (w *World) BlockAt(pos cube.Pos) (name string, properties map[string]any, found bool) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+	chunkPos, _ := cubePosInChunk(pos)
+
+	ch, ok, err := w.loadChunkLocked(chunkPos)
+	if err != nil || !ok {
+		return "", nil, false
+	}
+	rid := ch.Block(uint8(pos.X()&0xf), int16(pos.Y()), uint8(pos.Z()&0xf), 0)
+	return w.BlockRegistry.RuntimeIDToState(rid)
+}
+
 func (w *World) StoreEntity(id entity.RuntimeID, es *entity.Entity) {
 	w.stateLock.Lock()
 	defer w.stateLock.Unlock()
@@ -393,6 +424,27 @@ func (w *World) UnpauseCapture(around cube.Pos, radius int32) {
 	w.pausedState = nil
 }
 
+// RedrawChunks calls the chunk update callback again for every chunk of this world
+func (w *World) RedrawChunks() error {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+	for pos := range w.StoredChunks {
+		ch, ok, err := w.loadChunkLocked(pos)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		var isPaused bool
+		if w.pausedState != nil {
+			_, isPaused = w.pausedState.chunks[pos]
+		}
+		w.onChunkUpdate(pos, ch.Chunk, isPaused)
+	}
+	return nil
+}
+
 func (w *World) IsPaused() bool {
 	return w.pausedState != nil
 }
@@ -573,9 +625,23 @@ func (w *World) Finish(playerData map[string]any, excludedMobs []string, withPla
 		}
 	}
 
+	if w.Scoreboard != nil {
+		d, err := nbt.MarshalEncoding(w.Scoreboard, nbt.LittleEndian)
+		if err != nil {
+			return err
+		}
+		err = ldb.Put([]byte("scoreboard"), d, nil)
+		if err != nil {
+			return err
+		}
+	}
+
 	// write metadata
 	s := w.provider.Settings()
 	s.Spawn = spawn
+	if w.Level.Spawn != nil {
+		s.Spawn = *w.Level.Spawn
+	}
 	s.Name = w.Name
 
 	// set gamerules
@@ -690,5 +756,20 @@ func (w *World) Finish(playerData map[string]any, excludedMobs []string, withPla
 	}
 
 	w.provider.SaveSettings(s)
+
+	// SaveSettings only knows raining or not, so the levels are set afterwards
+	if w.Level.RainLevel != nil {
+		ld.RainLevel = *w.Level.RainLevel
+	}
+	if w.Level.LightningLevel != nil {
+		ld.LightningLevel = *w.Level.LightningLevel
+	}
+	if w.Level.Difficulty != nil {
+		ld.Difficulty = *w.Level.Difficulty
+	}
+	if w.Level.GameType != nil {
+		ld.GameType = *w.Level.GameType
+	}
+
 	return w.provider.Close()
 }
@@ -3,10 +3,35 @@ declare const console: {
 };
 
 
+/**
+ * Scripts run on an event loop, timer callbacks never run at the same time as event callbacks.
+ * Timers are cancelled when the session ends.
+ */
+declare type TimerHandle = object;
+
+/**
+ * Calls a function once after a delay in milliseconds.
+ */
+declare function setTimeout(callback: (...args: any[]) => void, delay?: number, ...args: any[]): TimerHandle;
+/**
+ * Calls a function repeatedly with a delay in milliseconds between calls.
+ */
+declare function setInterval(callback: (...args: any[]) => void, delay?: number, ...args: any[]): TimerHandle;
+/**
+ * Calls a function after the current callback returned.
+ */
+declare function setImmediate(callback: (...args: any[]) => void, ...args: any[]): TimerHandle;
+declare function clearTimeout(handle: TimerHandle): void;
+declare function clearInterval(handle: TimerHandle): void;
+declare function clearImmediate(handle: TimerHandle): void;
+
+
 /**
  * Names of events that can be registered.
  */
-declare type EventNames = 'EntityAdd' | 'EntityDataUpdate' | 'ChunkAdd' | 'BlockUpdate' | 'SpawnParticle' | 'Packet';
+declare type EventNames = 'EntityAdd' | 'EntityDataUpdate' | 'ChunkAdd' | 'BlockUpdate' | 'SpawnParticle' | 'Packet'
+    | 'Text' | 'PlayerAdd' | 'PlayerRemove' | 'ContainerOpen' | 'ChangeDimension' | 'EntityRemove'
+    | 'WorldSaveStart' | 'WorldSaveEnd' | 'Disconnect';
 
 
 /**
@@ -69,8 +94,89 @@ declare type SpawnParticleCallback = (name: string, pos: [number, number, number
  * @param packet - The packet data.
  * @param toServer - A boolean indicating whether the packet is being sent from the client to the server (`true`) or from the server to the client (`false`).
  * @param time - The time the particle was spawned.
+ * @returns `true` to drop the packet, or a packet object to send instead of it, either the modified `packet`
+ *   or an object with the fields of a packet of the same name.
+ */
+declare type PacketCallback = (name: string, packet: any, toServer: boolean, time: number) => boolean | object | void;
+
+
+/**
+ * Callback for the `Text` event.
+ * 
+ * @param sender - The name of the player who sent the message, empty for messages from the server.
+ * @param message - The message without the sender.
+ * @param textType - The type of the text packet.
+ * @param toServer - A boolean indicating whether the message is being sent by the client (`true`) or the server (`false`).
+ * @param time - The time the message was received.
+ */
+declare type TextCallback = (sender: string, message: string, textType: number, toServer: boolean, time: number) => void;
+
+
+/**
+ * Callback for the `PlayerAdd` and `PlayerRemove` events.
+ * 
+ * @param player - The player added to or removed from the player list.
+ * @param time - The time the player list was updated.
+ */
+declare type PlayerListCallback = (player: Player, time: number) => void;
+
+
+/**
+ * Callback for the `ContainerOpen` event.
+ * 
+ * @param container - The opened container.
+ * @param items - The items in the container, empty slots are left out.
+ * @param time - The time the content was received.
+ */
+declare type ContainerOpenCallback = (container: Container, items: ContainerItem[], time: number) => void;
+
+
+/**
+ * Callback for the `ChangeDimension` event.
+ * 
+ * @param dimension - The id of the new dimension, 0 overworld, 1 nether, 2 end.
+ * @param pos - The position in the new dimension.
+ * @param time - The time the dimension was changed.
+ */
+declare type ChangeDimensionCallback = (dimension: number, pos: [number, number, number], time: number) => void;
+
+
+/**
+ * Callback for the `EntityRemove` event.
+ * 
+ * @param entity - The entity being removed, it is still saved with the world.
+ * @param time - The time the entity was removed.
+ */
+declare type EntityRemoveCallback = (entity: Entity, time: number) => void;
+
+
+/**
+ * Callback for the `WorldSaveStart` event.
+ * 
+ * @param name - The name of the world.
+ * @param chunks - The number of chunks being saved.
+ * @param time - The time saving started.
+ */
+declare type WorldSaveStartCallback = (name: string, chunks: number, time: number) => void;
+
+
+/**
+ * Callback for the `WorldSaveEnd` event.
+ * 
+ * @param name - The name of the world.
+ * @param filename - The path of the .mcworld file.
+ * @param error - The error that stopped saving, empty if it was saved.
+ * @param time - The time saving finished.
  */
-declare type PacketCallback = (name: string, packet: any, toServer: boolean, time: number) => void;
+declare type WorldSaveEndCallback = (name: string, filename: string, error: string, time: number) => void;
+
+
+/**
+ * Callback for the `Disconnect` event.
+ * 
+ * @param time - The time the session ended.
+ */
+declare type DisconnectCallback = (time: number) => void;
 
 
 declare const events: {
@@ -84,6 +190,15 @@ declare const events: {
      *   - 'BlockUpdate': Triggered when a block is updated.
      *   - 'SpawnParticle': Triggered when a particle is spawned.
      *   - 'Packet': Triggered when a packet is received.
+     *   - 'Text': Triggered when a chat message or other text is sent.
+     *   - 'PlayerAdd': Triggered when a player is added to the player list.
+     *   - 'PlayerRemove': Triggered when a player is removed from the player list.
+     *   - 'ContainerOpen': Triggered when the content of an opened container is received.
+     *   - 'ChangeDimension': Triggered when the player changes dimension.
+     *   - 'EntityRemove': Triggered when an entity is removed.
+     *   - 'WorldSaveStart': Triggered when a world starts saving.
+     *   - 'WorldSaveEnd': Triggered when a world finished saving.
+     *   - 'Disconnect': Triggered when the session ends.
      * 
      * @param callback - The callback function to invoke when the event occurs. The parameters of the callback function vary based on the event:
      *   - For 'EntityAdd':
@@ -108,7 +223,16 @@ declare const events: {
      *   - 'ChunkAdd': Triggered when a new chunk is added.
      *   - 'BlockUpdate': Triggered when a block is updated.
      *   - 'SpawnParticle': Triggered when a particle is spawned.
-	 *   - 'Packet': Triggered when a packet is received.
+     *   - 'Packet': Triggered when a packet is received.
+     *   - 'Text': Triggered when a chat message or other text is sent.
+     *   - 'PlayerAdd': Triggered when a player is added to the player list.
+     *   - 'PlayerRemove': Triggered when a player is removed from the player list.
+     *   - 'ContainerOpen': Triggered when the content of an opened container is received.
+     *   - 'ChangeDimension': Triggered when the player changes dimension.
+     *   - 'EntityRemove': Triggered when an entity is removed.
+     *   - 'WorldSaveStart': Triggered when a world starts saving.
+     *   - 'WorldSaveEnd': Triggered when a world finished saving.
+     *   - 'Disconnect': Triggered when the session ends.
      * 
      * @param callback - The callback function to invoke when the event occurs. The parameters of the callback function vary based on the event:
      *   - For 'EntityDataUpdate':
@@ -133,7 +257,16 @@ declare const events: {
      *   - 'ChunkAdd': Triggered when a new chunk is added.
      *   - 'BlockUpdate': Triggered when a block is updated.
      *   - 'SpawnParticle': Triggered when a particle is spawned.
-	 *   - 'Packet': Triggered when a packet is received.
+     *   - 'Packet': Triggered when a packet is received.
+     *   - 'Text': Triggered when a chat message or other text is sent.
+     *   - 'PlayerAdd': Triggered when a player is added to the player list.
+     *   - 'PlayerRemove': Triggered when a player is removed from the player list.
+     *   - 'ContainerOpen': Triggered when the content of an opened container is received.
+     *   - 'ChangeDimension': Triggered when the player changes dimension.
+     *   - 'EntityRemove': Triggered when an entity is removed.
+     *   - 'WorldSaveStart': Triggered when a world starts saving.
+     *   - 'WorldSaveEnd': Triggered when a world finished saving.
+     *   - 'Disconnect': Triggered when the session ends.
      * 
      * @param callback - The callback function to invoke when the event occurs. The parameters of the callback function vary based on the event:
      *   - For 'ChunkAdd':
@@ -156,7 +289,16 @@ declare const events: {
      *   - 'ChunkAdd': Triggered when a new chunk is added.
      *   - 'BlockUpdate': Triggered when a block is updated.
      *   - 'SpawnParticle': Triggered when a particle is spawned.
-	 *   - 'Packet': Triggered when a packet is received.
+     *   - 'Packet': Triggered when a packet is received.
+     *   - 'Text': Triggered when a chat message or other text is sent.
+     *   - 'PlayerAdd': Triggered when a player is added to the player list.
+     *   - 'PlayerRemove': Triggered when a player is removed from the player list.
+     *   - 'ContainerOpen': Triggered when the content of an opened container is received.
+     *   - 'ChangeDimension': Triggered when the player changes dimension.
+     *   - 'EntityRemove': Triggered when an entity is removed.
+     *   - 'WorldSaveStart': Triggered when a world starts saving.
+     *   - 'WorldSaveEnd': Triggered when a world finished saving.
+     *   - 'Disconnect': Triggered when the session ends.
      * 
      * @param callback - The callback function to invoke when the event occurs. The parameters of the callback function vary based on the event:
      *   - For 'BlockUpdate':
@@ -181,6 +323,15 @@ declare const events: {
      *   - 'BlockUpdate': Triggered when a block is updated.
      *   - 'SpawnParticle': Triggered when a particle is spawned.
      *   - 'Packet': Triggered when a packet is received.
+     *   - 'Text': Triggered when a chat message or other text is sent.
+     *   - 'PlayerAdd': Triggered when a player is added to the player list.
+     *   - 'PlayerRemove': Triggered when a player is removed from the player list.
+     *   - 'ContainerOpen': Triggered when the content of an opened container is received.
+     *   - 'ChangeDimension': Triggered when the player changes dimension.
+     *   - 'EntityRemove': Triggered when an entity is removed.
+     *   - 'WorldSaveStart': Triggered when a world starts saving.
+     *   - 'WorldSaveEnd': Triggered when a world finished saving.
+     *   - 'Disconnect': Triggered when the session ends.
      * 
      * @param callback - The callback function to invoke when the event occurs. The parameters of the callback function vary based on the event:
      *   - For 'SpawnParticle':
@@ -207,6 +358,15 @@ declare const events: {
      *   - 'BlockUpdate': Triggered when a block is updated.
      *   - 'SpawnParticle': Triggered when a particle is spawned.
      *   - 'Packet': Triggered when a packet is received.
+     *   - 'Text': Triggered when a chat message or other text is sent.
+     *   - 'PlayerAdd': Triggered when a player is added to the player list.
+     *   - 'PlayerRemove': Triggered when a player is removed from the player list.
+     *   - 'ContainerOpen': Triggered when the content of an opened container is received.
+     *   - 'ChangeDimension': Triggered when the player changes dimension.
+     *   - 'EntityRemove': Triggered when an entity is removed.
+     *   - 'WorldSaveStart': Triggered when a world starts saving.
+     *   - 'WorldSaveEnd': Triggered when a world finished saving.
+     *   - 'Disconnect': Triggered when the session ends.
      * 
      * @param callback - The callback function to invoke when the event occurs. The parameters of the callback function vary based on the event:
      *   - For 'Packet':
@@ -220,10 +380,172 @@ declare const events: {
      *     if(name === 'LevelSoundEvent') {
      *         console.log(`Packet ${name} ${JSON.stringify(packet)}`);
      *     }
+     *     if(name === 'SetTime') {
+     *         packet.Time = 6000;
+     *         return packet;
+     *     }
      * });
      * 
      */
     register(name: 'Packet', callback: PacketCallback): void;
+    /**
+     * Registers a callback for chat messages and other text.
+     * 
+     * @example
+     * events.register('Text', (sender, message, textType, toServer, time) => {
+     *     if(sender !== '') {
+     *         console.log(`<${sender}> ${message}`);
+     *     }
+     * });
+     */
+    register(name: 'Text', callback: TextCallback): void;
+    /**
+     * Registers a callback for players being added to the player list.
+     * 
+     * @example
+     * events.register('PlayerAdd', (player, time) => {
+     *     console.log(`PlayerAdd ${player.Username}`);
+     * });
+     */
+    register(name: 'PlayerAdd', callback: PlayerListCallback): void;
+    /**
+     * Registers a callback for players being removed from the player list.
+     * 
+     * @example
+     * events.register('PlayerRemove', (player, time) => {
+     *     console.log(`PlayerRemove ${player.Username}`);
+     * });
+     */
+    register(name: 'PlayerRemove', callback: PlayerListCallback): void;
+    /**
+     * Registers a callback for containers the player opens, it runs once the content was received.
+     * 
+     * @example
+     * events.register('ContainerOpen', (container, items, time) => {
+     *     console.log(`ContainerOpen ${container.Position} ${items.map(item => item.Name)}`);
+     * });
+     */
+    register(name: 'ContainerOpen', callback: ContainerOpenCallback): void;
+    /**
+     * Registers a callback for the player changing dimension.
+     * 
+     * @example
+     * events.register('ChangeDimension', (dimension, pos, time) => {
+     *     console.log(`ChangeDimension ${dimension}`);
+     * });
+     */
+    register(name: 'ChangeDimension', callback: ChangeDimensionCallback): void;
+    /**
+     * Registers a callback for entities being removed by the server.
+     * 
+     * @example
+     * events.register('EntityRemove', (entity, time) => {
+     *     console.log(`EntityRemove ${entity.EntityType}`);
+     * });
+     */
+    register(name: 'EntityRemove', callback: EntityRemoveCallback): void;
+    /**
+     * Registers a callback for a world starting to save.
+     * 
+     * @example
+     * events.register('WorldSaveStart', (name, chunks, time) => {
+     *     console.log(`WorldSaveStart ${name} ${chunks}`);
+     * });
+     */
+    register(name: 'WorldSaveStart', callback: WorldSaveStartCallback): void;
+    /**
+     * Registers a callback for a world that finished saving.
+     * 
+     * @example
+     * events.register('WorldSaveEnd', (name, filename, error, time) => {
+     *     console.log(`WorldSaveEnd ${filename} ${error}`);
+     * });
+     */
+    register(name: 'WorldSaveEnd', callback: WorldSaveEndCallback): void;
+    /**
+     * Registers a callback for the end of the session.
+     * 
+     * @example
+     * events.register('Disconnect', (time) => {
+     *     console.log('Disconnect');
+     * });
+     */
+    register(name: 'Disconnect', callback: DisconnectCallback): void;
+};
+
+
+declare const proxy: {
+    /**
+     * Sends a packet to the client, errors are written to the script log.
+     * 
+     * @param name - The name of the packet, e.g., 'Text'.
+     * @param fields - The fields of the packet, fields that are left out are zero.
+     * @returns `true` if the packet was sent.
+     * 
+     * @example
+     * proxy.sendToClient('Text', {TextType: 0, Message: 'hello'});
+     */
+    sendToClient(name: string, fields: {[k: string]: any}): boolean;
+    /**
+     * Sends a packet to the server, errors are written to the script log.
+     * 
+     * @param name - The name of the packet, e.g., 'CommandRequest'.
+     * @param fields - The fields of the packet, fields that are left out are zero.
+     * @returns `true` if the packet was sent.
+     */
+    sendToServer(name: string, fields: {[k: string]: any}): boolean;
+};
+
+
+/**
+ * A parameter of a command registered by a script.
+ */
+declare type CommandParam = {
+    /**
+     * The name of the parameter, it is the key in the arguments passed to the callback.
+     */
+    name: string;
+    /**
+     * The type of the parameter, 'text' takes the rest of the command line and has to be last.
+     * Positions are passed as [x, y, z], coordinates starting with ~ are relative to the player.
+     */
+    type: 'int' | 'float' | 'string' | 'target' | 'position' | 'text';
+    /**
+     * Whether the parameter can be left out, only the last parameters can be optional.
+     */
+    optional?: boolean;
+}
+
+
+/**
+ * Callback for a command registered by a script.
+ * 
+ * @param args - The parsed arguments by parameter name, optional parameters that were left out are missing.
+ * @param reply - Sends a chat message to the player.
+ */
+declare type CommandCallback = (args: {[k: string]: any}, reply: (message: string) => void) => void;
+
+
+declare const commands: {
+    /**
+     * Registers an in-game command, the parameters are shown to the client for tab completion.
+     * The client only receives the command list once, so commands have to be registered when the script loads,
+     * not from timers, promises or events.
+     * 
+     * @param name - The name of the command, lowercase without spaces.
+     * @param description - The description shown in the command list.
+     * @param params - The parameters of the command.
+     * @param callback - The function to run when the command is used.
+     * 
+     * @example
+     * commands.register('mark', 'save a position', [
+     *     {name: 'label', type: 'string'},
+     *     {name: 'pos', type: 'position', optional: true},
+     * ], (args, reply) => {
+     *     reply(`marked ${args.label} at ${args.pos}`);
+     * });
+     */
+    register(name: string, description: string, params: CommandParam[], callback: CommandCallback): void;
 };
 
 
@@ -267,6 +589,79 @@ declare type Entity = {
 }
 
 
+/**
+ * Represents a player in the player list.
+ */
+declare type Player = {
+    /**
+     * The UUID of the player.
+     */
+    UUID: string;
+    /**
+     * The name of the player.
+     */
+    Username: string;
+    /**
+     * The Xbox user id of the player, empty on servers without Xbox authentication.
+     */
+    XUID: string;
+    /**
+     * The unique identifier of the player entity.
+     */
+    EntityUniqueID: number;
+}
+
+
+/**
+ * Represents a container the player opened.
+ */
+declare type Container = {
+    /**
+     * The window identifier of the container.
+     */
+    WindowID: number;
+    /**
+     * The type of the container.
+     */
+    ContainerType: number;
+    /**
+     * The position of the container block.
+     */
+    Position: [number, number, number];
+    /**
+     * The unique identifier of the entity, if the container is an entity.
+     */
+    EntityUniqueID: number;
+}
+
+
+/**
+ * Represents an item in a container.
+ */
+declare type ContainerItem = {
+    /**
+     * The slot of the item in the container.
+     */
+    Slot: number;
+    /**
+     * The identifier of the item, e.g., 'minecraft:diamond'.
+     */
+    Name: string;
+    /**
+     * The metadata value of the item.
+     */
+    Metadata: number;
+    /**
+     * The quantity of items in the stack.
+     */
+    Count: number;
+    /**
+     * The NBT data associated with the item.
+     */
+    NBT: {[k: string]: any};
+}
+
+
 /**
  * Represents an item instance in the game.
  */
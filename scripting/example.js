@@ -8,10 +8,20 @@ events.register('EntityDataUpdate', (entity, metadata, time) => {
 });
 
 
+let chunksAdded = 0;
 events.register('ChunkAdd', (pos, time) => {
     console.log(`ChunkAdd ${pos}`);
+    chunksAdded++;
 });
 
+// timers run between the events, so they can use the same variables
+setInterval(() => {
+    if (chunksAdded > 0) {
+        console.log(`${chunksAdded} chunks in the last minute`);
+        chunksAdded = 0;
+    }
+}, 60 * 1000);
+
 
 events.register('BlockUpdate', (name, properties, pos, time) => {
     console.log(`BlockUpdate ${name}`);
@@ -26,4 +36,48 @@ events.register('Packet', (name, packet, toServer, time) => {
     if(name === 'LevelSoundEvent') {
         console.log(`Packet ${name} ${JSON.stringify(packet)}`);
     }
-});
\ No newline at end of file
+});
+
+events.register('Text', (sender, message, textType, toServer, time) => {
+    console.log(`Text <${sender}> ${message}`);
+});
+
+
+events.register('PlayerAdd', (player, time) => {
+    console.log(`PlayerAdd ${player.Username}`);
+});
+
+
+events.register('PlayerRemove', (player, time) => {
+    console.log(`PlayerRemove ${player.Username}`);
+});
+
+
+events.register('ContainerOpen', (container, items, time) => {
+    console.log(`ContainerOpen ${container.Position} ${items.length} items`);
+});
+
+
+events.register('ChangeDimension', (dimension, pos, time) => {
+    console.log(`ChangeDimension ${dimension}`);
+});
+
+
+events.register('EntityRemove', (entity, time) => {
+    console.log(`EntityRemove ${entity.EntityType}`);
+});
+
+
+events.register('WorldSaveStart', (name, chunks, time) => {
+    console.log(`WorldSaveStart ${name} ${chunks}`);
+});
+
+
+events.register('WorldSaveEnd', (name, filename, error, time) => {
+    console.log(`WorldSaveEnd ${filename} ${error}`);
+});
+
+
+events.register('Disconnect', (time) => {
+    console.log('Disconnect');
+});
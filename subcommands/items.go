@@ -0,0 +1,38 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+
+	"github.com/bedrock-tool/bedrocktool/handlers"
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/proxy"
+)
+
+type ItemsCMD struct {
+	ServerAddress     string
+	EnableClientCache bool
+}
+
+func (*ItemsCMD) Name() string     { return "items" }
+func (*ItemsCMD) Synopsis() string { return "export the creative item catalogue of a server" }
+func (c *ItemsCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ServerAddress, "address", "", "remote server address")
+	f.BoolVar(&c.EnableClientCache, "client-cache", true, "Enable Client Cache")
+}
+
+func (c *ItemsCMD) Execute(ctx context.Context) error {
+	proxyContext, err := proxy.New(ctx, true, c.EnableClientCache)
+	if err != nil {
+		return err
+	}
+	proxyContext.AddHandler(handlers.NewItemCatalogue())
+
+	server := ctx.Value(utils.ConnectInfoKey).(*utils.ConnectInfo)
+	return proxyContext.Run(server)
+}
+
+func init() {
+	commands.RegisterCommand(&ItemsCMD{})
+}
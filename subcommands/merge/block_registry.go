@@ -1,6 +1,7 @@
 package merge
 
 import (
+	"sync"
 	_ "unsafe"
 
 	"github.com/df-mc/dragonfly/server/world"
@@ -9,6 +10,8 @@ import (
 type BlockRegistry struct {
 	world.BlockRegistry
 	Rids map[uint32]Block
+	// chunks may be decoded from multiple goroutines
+	l sync.RWMutex
 }
 
 type Block struct {
@@ -19,12 +22,19 @@ type Block struct {
 //go:linkname networkBlockHash github.com/df-mc/dragonfly/server/world.networkBlockHash
 func networkBlockHash(name string, properties map[string]any) uint32
 
+func (b *BlockRegistry) custom(rid uint32) (Block, bool) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	block, ok := b.Rids[rid]
+	return block, ok
+}
+
 func (b *BlockRegistry) RuntimeIDToState(runtimeID uint32) (name string, properties map[string]any, found bool) {
 	name, properties, found = b.BlockRegistry.RuntimeIDToState(runtimeID)
 	if found {
 		return
 	}
-	block := b.Rids[runtimeID]
+	block, _ := b.custom(runtimeID)
 	return block.name, block.properties, true
 }
 
@@ -34,7 +44,9 @@ func (b *BlockRegistry) StateToRuntimeID(name string, properties map[string]any)
 		return
 	}
 	runtimeID = networkBlockHash(name, properties)
+	b.l.Lock()
 	b.Rids[runtimeID] = Block{name, properties}
+	b.l.Unlock()
 	return runtimeID, true
 }
 
@@ -43,7 +55,7 @@ func (b *BlockRegistry) BlockByRuntimeID(rid uint32) (world.Block, bool) {
 	if ok {
 		return block, true
 	}
-	block2, ok := b.Rids[rid]
+	block2, ok := b.custom(rid)
 	return world.UnknownBlock{
 		BlockState: world.BlockState{
 			Name:       block2.name,
@@ -61,42 +73,42 @@ func (b *BlockRegistry) BlockCount() int {
 }
 
 func (b *BlockRegistry) RandomTickBlock(rid uint32) bool {
-	if _, ok := b.Rids[rid]; ok {
+	if _, ok := b.custom(rid); ok {
 		return false
 	}
 	return b.BlockRegistry.RandomTickBlock(rid)
 }
 
 func (b *BlockRegistry) FilteringBlock(rid uint32) uint8 {
-	if _, ok := b.Rids[rid]; ok {
+	if _, ok := b.custom(rid); ok {
 		return 15
 	}
 	return b.BlockRegistry.FilteringBlock(rid)
 }
 
 func (b *BlockRegistry) LightBlock(rid uint32) uint8 {
-	if _, ok := b.Rids[rid]; ok {
+	if _, ok := b.custom(rid); ok {
 		return 0
 	}
 	return b.BlockRegistry.LightBlock(rid)
 }
 
 func (b *BlockRegistry) NBTBlock(rid uint32) bool {
-	if _, ok := b.Rids[rid]; ok {
+	if _, ok := b.custom(rid); ok {
 		return false
 	}
 	return b.BlockRegistry.NBTBlock(rid)
 }
 
 func (b *BlockRegistry) LiquidDisplacingBlock(rid uint32) bool {
-	if _, ok := b.Rids[rid]; ok {
+	if _, ok := b.custom(rid); ok {
 		return true
 	}
 	return b.BlockRegistry.LiquidDisplacingBlock(rid)
 }
 
 func (b *BlockRegistry) LiquidBlock(rid uint32) bool {
-	if _, ok := b.Rids[rid]; ok {
+	if _, ok := b.custom(rid); ok {
 		return false
 	}
 	return b.BlockRegistry.LiquidBlock(rid)
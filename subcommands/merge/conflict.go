@@ -0,0 +1,139 @@
+package merge
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+)
+
+// conflictPolicy decides which input is kept when multiple inputs have the same chunk
+type conflictPolicy string
+
+const (
+	conflictNewest     conflictPolicy = "newest"
+	conflictFirst      conflictPolicy = "first"
+	conflictLast       conflictPolicy = "last"
+	conflictMostBlocks conflictPolicy = "most-blocks"
+)
+
+func parseConflictPolicy(s string) (conflictPolicy, error) {
+	switch p := conflictPolicy(s); p {
+	case conflictNewest, conflictFirst, conflictLast, conflictMostBlocks:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q, expected newest, first, last or most-blocks", s)
+}
+
+// chunkKey is a chunk in the output world
+type chunkKey struct {
+	dim int
+	pos world.ChunkPos
+}
+
+// candidate is an input that has a chunk
+type candidate struct {
+	input  int
+	blocks int
+}
+
+// priority orders the inputs for everything that isnt a chunk, the first input has the highest priority
+func priority(policy conflictPolicy, worlds []worldInstance) []int {
+	order := make([]int, len(worlds))
+	for i := range order {
+		order[i] = i
+	}
+	switch policy {
+	case conflictFirst:
+	case conflictNewest:
+		// stable so inputs without a timestamp keep the order of last
+		slices.Reverse(order)
+		slices.SortStableFunc(order, func(a, b int) int {
+			return cmp.Compare(worlds[b].lastPlayed, worlds[a].lastPlayed)
+		})
+	default:
+		slices.Reverse(order)
+	}
+	return order
+}
+
+// pick returns the input that is kept for a chunk
+func pick(policy conflictPolicy, worlds []worldInstance, candidates []candidate) int {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		switch policy {
+		case conflictFirst:
+		case conflictLast:
+			best = c
+		case conflictNewest:
+			if worlds[c.input].lastPlayed >= worlds[best.input].lastPlayed {
+				best = c
+			}
+		case conflictMostBlocks:
+			if c.blocks >= best.blocks {
+				best = c
+			}
+		}
+	}
+	return best.input
+}
+
+// countBlocks counts the non air blocks of a chunk
+func countBlocks(c *chunk.Chunk, airRID uint32) (n int) {
+	for _, sub := range c.Sub() {
+		if sub.Empty() {
+			continue
+		}
+		layer := sub.Layer(0)
+		if p := layer.Palette(); p.Len() == 1 && p.Value(0) == airRID {
+			continue
+		}
+		for x := byte(0); x < 16; x++ {
+			for y := byte(0); y < 16; y++ {
+				for z := byte(0); z < 16; z++ {
+					if layer.At(x, y, z) != airRID {
+						n++
+					}
+				}
+			}
+		}
+	}
+	return n
+}
+
+// overlapReport counts the overlapping chunks for each combination of inputs
+type overlapReport struct {
+	counts map[string]int
+	kept   map[string]map[int]int
+}
+
+func (r *overlapReport) add(worlds []worldInstance, candidates []candidate, winner int) {
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		names = append(names, worlds[c.input].Name)
+	}
+	key := strings.Join(names, " + ")
+	r.counts[key]++
+	if r.kept[key] == nil {
+		r.kept[key] = make(map[int]int)
+	}
+	r.kept[key][winner]++
+}
+
+func (r *overlapReport) print(worlds []worldInstance) {
+	if len(r.counts) == 0 {
+		fmt.Println("No overlapping chunks")
+		return
+	}
+	fmt.Println("Overlapping chunks:")
+	for _, key := range slices.Sorted(maps.Keys(r.counts)) {
+		fmt.Printf("  %s: %d chunks\n", key, r.counts[key])
+		for input, n := range r.kept[key] {
+			fmt.Printf("    kept %d from %s\n", n, worlds[input].Name)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package merge
+
+import (
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+)
+
+// parseDimensionMapping parses src or src:dst
+func parseDimensionMapping(s string) (src, dst int, err error) {
+	srcName, dstName, remap := strings.Cut(s, ":")
+	src, err = behaviourpack.ParseDimension(srcName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !remap {
+		return src, src, nil
+	}
+	dst, err = behaviourpack.ParseDimension(dstName)
+	if err != nil {
+		return 0, 0, err
+	}
+	return src, dst, nil
+}
+
+// blockSpan returns the lowest and highest y that isnt air in the chunk, false if it is all air
+func blockSpan(c *chunk.Chunk, airRID uint32) (low, high int, ok bool) {
+	filled := func(sub *chunk.SubChunk) bool {
+		if sub.Empty() {
+			return false
+		}
+		for _, layer := range sub.Layers() {
+			if p := layer.Palette(); p.Len() != 1 || p.Value(0) != airRID {
+				return true
+			}
+		}
+		return false
+	}
+	layerFilled := func(sub *chunk.SubChunk, y byte) bool {
+		for _, layer := range sub.Layers() {
+			for x := byte(0); x < 16; x++ {
+				for z := byte(0); z < 16; z++ {
+					if layer.At(x, y, z) != airRID {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+
+	subs := c.Sub()
+	for i := 0; i < len(subs) && !ok; i++ {
+		if !filled(subs[i]) {
+			continue
+		}
+		for y := byte(0); y < 16; y++ {
+			if layerFilled(subs[i], y) {
+				low, ok = int(c.SubY(int16(i)))+int(y), true
+				break
+			}
+		}
+	}
+	if !ok {
+		return 0, 0, false
+	}
+	for i := len(subs) - 1; i >= 0; i-- {
+		if !filled(subs[i]) {
+			continue
+		}
+		for y := 15; y >= 0; y-- {
+			if layerFilled(subs[i], byte(y)) {
+				return low, int(c.SubY(int16(i))) + y, true
+			}
+		}
+	}
+	return low, low, true
+}
+
+// fitsRange checks if every block of the chunk is inside r after moving it by yOffset
+func fitsRange(c *chunk.Chunk, r cube.Range, yOffset int, airRID uint32) bool {
+	src := c.Range()
+	if src[0]+yOffset >= r[0] && src[1]+yOffset <= r[1] {
+		return true
+	}
+	low, high, ok := blockSpan(c, airRID)
+	if !ok {
+		return true
+	}
+	return low+yOffset >= r[0] && high+yOffset <= r[1]
+}
+
+// moveChunk copies the chunk into a new chunk with the range r, moved up by yOffset.
+// blocks that end up outside of r are dropped, fitsRange is checked before.
+func moveChunk(c *chunk.Chunk, r cube.Range, yOffset int, airRID uint32) *chunk.Chunk {
+	src := c.Range()
+	out := chunk.New(airRID, r)
+
+	// whole sub chunks can be moved when the offset is aligned
+	if yOffset%16 == 0 {
+		for i, sub := range c.Sub() {
+			y := int(c.SubY(int16(i))) + yOffset
+			if y < r[0] || y > r[1] {
+				continue
+			}
+			out.Sub()[out.SubIndex(int16(y))] = sub
+		}
+	} else {
+		for i, sub := range c.Sub() {
+			if sub.Empty() {
+				continue
+			}
+			baseY := int(c.SubY(int16(i)))
+			for layerIndex, layer := range sub.Layers() {
+				if p := layer.Palette(); p.Len() == 1 && p.Value(0) == airRID {
+					continue
+				}
+				for y := byte(0); y < 16; y++ {
+					dstY := baseY + int(y) + yOffset
+					if dstY < r[0] || dstY > r[1] {
+						continue
+					}
+					for x := byte(0); x < 16; x++ {
+						for z := byte(0); z < 16; z++ {
+							if rid := layer.At(x, y, z); rid != airRID {
+								out.SetBlock(x, int16(dstY), z, uint8(layerIndex), rid)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for y := src[0]; y <= src[1]; y++ {
+		dstY := y + yOffset
+		if dstY < r[0] || dstY > r[1] {
+			continue
+		}
+		for x := uint8(0); x < 16; x++ {
+			for z := uint8(0); z < 16; z++ {
+				out.SetBiome(x, int16(dstY), z, c.Biome(x, int16(y), z))
+			}
+		}
+	}
+	return out
+}
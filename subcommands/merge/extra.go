@@ -0,0 +1,140 @@
+package merge
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+// shiftNBT decodes little endian nbt, lets fn move the positions in it and encodes it again
+func shiftNBT(value []byte, fn func(m map[string]any)) ([]byte, error) {
+	var m map[string]any
+	err := nbt.UnmarshalEncoding(value, &m, nbt.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	fn(m)
+	return nbt.MarshalEncoding(m, nbt.LittleEndian)
+}
+
+// shiftMap moves the center of a map by the offset of its world
+func shiftMap(w *worldInstance, value []byte) ([]byte, error) {
+	return shiftNBT(value, func(m map[string]any) {
+		if x, ok := m["xCenter"].(int32); ok {
+			m["xCenter"] = x + w.offset[0]*16
+		}
+		if z, ok := m["zCenter"].(int32); ok {
+			m["zCenter"] = z + w.offset[1]*16
+		}
+	})
+}
+
+// shiftPlayer moves the position of a player by the offset of its world
+func shiftPlayer(w *worldInstance, value []byte) ([]byte, error) {
+	return shiftNBT(value, func(m map[string]any) {
+		pos, ok := m["Pos"].([]any)
+		if !ok || len(pos) != 3 {
+			return
+		}
+		x, _ := pos[0].(float32)
+		y, _ := pos[1].(float32)
+		z, _ := pos[2].(float32)
+		m["Pos"] = []any{x + float32(w.offset[0]*16), y + float32(w.yOffset), z + float32(w.offset[1]*16)}
+	})
+}
+
+type extraKeys struct {
+	name      string
+	match     func(key string) bool
+	transform func(w *worldInstance, value []byte) ([]byte, error)
+}
+
+var mergedKeys = []extraKeys{
+	{
+		name:      "maps",
+		match:     func(key string) bool { return strings.HasPrefix(key, "map_") },
+		transform: shiftMap,
+	},
+	{
+		name: "players",
+		match: func(key string) bool {
+			return key == "~local_player" || strings.HasPrefix(key, "player_")
+		},
+		transform: shiftPlayer,
+	},
+	{
+		name:  "scoreboard",
+		match: func(key string) bool { return key == "scoreboard" },
+	},
+}
+
+// mergeExtra copies maps, players and the scoreboard into out,
+// when a key exists in multiple inputs the one first in order is kept
+func mergeExtra(out *mcdb.DB, worlds []worldInstance, order []int) error {
+	counts := make(map[string]int)
+	written := make(map[string]bool)
+	for _, i := range order {
+		w := &worlds[i]
+		it := w.db.LDB().NewIterator(nil, nil)
+		for it.Next() {
+			key := string(it.Key())
+			if written[key] {
+				continue
+			}
+			for _, ek := range mergedKeys {
+				if !ek.match(key) {
+					continue
+				}
+				value := append([]byte(nil), it.Value()...)
+				if ek.transform != nil && (w.offset != (world.ChunkPos{}) || w.yOffset != 0) {
+					var err error
+					value, err = ek.transform(w, value)
+					if err != nil {
+						it.Release()
+						return fmt.Errorf("%s %s: %w", w.Name, key, err)
+					}
+				}
+				err := out.LDB().Put([]byte(key), value, nil)
+				if err != nil {
+					it.Release()
+					return err
+				}
+				written[key] = true
+				counts[ek.name]++
+				break
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	for _, ek := range mergedKeys {
+		fmt.Printf("Merged %d %s\n", counts[ek.name], ek.name)
+	}
+	return nil
+}
+
+// mergeLevelDat uses the settings of the first input in order, with the spawn moved by its offsets
+// and the play time of the newest input
+func mergeLevelDat(out *mcdb.DB, worlds []worldInstance, order []int) {
+	base := &worlds[order[0]]
+	ldat := out.LevelDat()
+	*ldat = *base.db.LevelDat()
+	ldat.SpawnX += base.offset[0] * 16
+	ldat.SpawnZ += base.offset[1] * 16
+	// 32767 places the spawn on the highest block
+	if ldat.SpawnY != math.MaxInt16 {
+		ldat.SpawnY += int32(base.yOffset)
+	}
+	for _, w := range worlds {
+		other := w.db.LevelDat()
+		ldat.LastPlayed = max(ldat.LastPlayed, other.LastPlayed)
+		ldat.CurrentTick = max(ldat.CurrentTick, other.CurrentTick)
+	}
+	fmt.Printf("Using level.dat of %s\n", base.Name)
+}
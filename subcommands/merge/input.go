@@ -0,0 +1,133 @@
+package merge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// chunkRange is an inclusive range of chunk positions
+type chunkRange struct {
+	min, max world.ChunkPos
+}
+
+func (r chunkRange) contains(pos world.ChunkPos) bool {
+	return pos[0] >= r.min[0] && pos[0] <= r.max[0] && pos[1] >= r.min[1] && pos[1] <= r.max[1]
+}
+
+func parseChunkRange(s string) (r chunkRange, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return r, fmt.Errorf("invalid chunk range %q, expected x1,z1,x2,z2", s)
+	}
+	var v [4]int32
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return r, fmt.Errorf("invalid chunk range %q: %w", s, err)
+		}
+		v[i] = int32(n)
+	}
+	r.min = world.ChunkPos{min(v[0], v[2]), min(v[1], v[3])}
+	r.max = world.ChunkPos{max(v[0], v[2]), max(v[1], v[3])}
+	return r, nil
+}
+
+// inputSpec is one world argument of merge,
+// path[;x;z][;include=x1,z1,x2,z2][;exclude=x1,z1,x2,z2][;dim=src[:dst]][;y=offset]
+type inputSpec struct {
+	path    string
+	offset  world.ChunkPos
+	include []chunkRange
+	exclude []chunkRange
+	// dims maps the selected dimensions of the input to the dimension in the output, all are kept when empty
+	dims    map[int]int
+	yOffset int
+}
+
+func parseInputSpec(arg string) (spec inputSpec, err error) {
+	parts := strings.Split(arg, ";")
+	spec.path = parts[0]
+
+	var positional []string
+	for _, part := range parts[1:] {
+		key, value, isOption := strings.Cut(part, "=")
+		if !isOption {
+			positional = append(positional, part)
+			continue
+		}
+		switch key {
+		case "include", "exclude":
+			r, err := parseChunkRange(value)
+			if err != nil {
+				return spec, fmt.Errorf("%s %w", spec.path, err)
+			}
+			if key == "include" {
+				spec.include = append(spec.include, r)
+			} else {
+				spec.exclude = append(spec.exclude, r)
+			}
+		case "dim":
+			src, dst, err := parseDimensionMapping(value)
+			if err != nil {
+				return spec, fmt.Errorf("%s %w", spec.path, err)
+			}
+			if spec.dims == nil {
+				spec.dims = make(map[int]int)
+			}
+			spec.dims[src] = dst
+		case "y":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return spec, fmt.Errorf("%s invalid y offset: %w", spec.path, err)
+			}
+			spec.yOffset = n
+		default:
+			return spec, fmt.Errorf("%s unknown option %q", spec.path, key)
+		}
+	}
+
+	switch len(positional) {
+	case 0:
+	case 2:
+		for i, p := range positional {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return spec, fmt.Errorf("%s %w", spec.path, err)
+			}
+			spec.offset[i] = int32(n)
+		}
+	default:
+		return spec, fmt.Errorf("%s expected an offset as ;x;z", spec.path)
+	}
+	return spec, nil
+}
+
+// targetDimension returns the output dimension for a dimension of the input, false if it isnt selected
+func (s *inputSpec) targetDimension(id int) (int, bool) {
+	if len(s.dims) == 0 {
+		return id, true
+	}
+	dst, ok := s.dims[id]
+	return dst, ok
+}
+
+// allows checks the include and exclude ranges for a position in the input world
+func (s *inputSpec) allows(pos world.ChunkPos) bool {
+	for _, r := range s.exclude {
+		if r.contains(pos) {
+			return false
+		}
+	}
+	if len(s.include) == 0 {
+		return true
+	}
+	for _, r := range s.include {
+		if r.contains(pos) {
+			return true
+		}
+	}
+	return false
+}
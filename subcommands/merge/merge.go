@@ -8,10 +8,10 @@ import (
 	"math"
 	"math/rand/v2"
 	"os"
-	"strconv"
-	"strings"
 
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
 	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/df-mc/dragonfly/server/world/mcdb"
 	"github.com/df-mc/goleveldb/leveldb/opt"
@@ -21,12 +21,15 @@ type MergeCMD struct {
 	f          *flag.FlagSet
 	showBounds bool
 	outPath    string
+	conflict   string
 }
 
 type worldInstance struct {
-	Name   string
-	db     *mcdb.DB
-	offset world.ChunkPos
+	inputSpec
+	Name string
+	db   *mcdb.DB
+	// level.dat LastPlayed, used as the capture time
+	lastPlayed int64
 }
 
 func (*MergeCMD) Name() string     { return "merge" }
@@ -36,6 +39,7 @@ func (c *MergeCMD) SetFlags(f *flag.FlagSet) {
 	c.f = f
 	f.BoolVar(&c.showBounds, "bounds", false, "show bounds instead of merge")
 	f.StringVar(&c.outPath, "out", "", "output folder")
+	f.StringVar(&c.conflict, "conflict", "last", "which chunk to keep when inputs overlap: newest, first, last or most-blocks")
 }
 
 func (c *MergeCMD) Execute(ctx context.Context) error {
@@ -48,23 +52,18 @@ func (c *MergeCMD) Execute(ctx context.Context) error {
 		Rids:          make(map[uint32]Block),
 	}
 
+	policy, err := parseConflictPolicy(c.conflict)
+	if err != nil {
+		return err
+	}
+
 	var worlds []worldInstance
-	for _, worldName := range c.f.Args() {
-		sp := strings.SplitN(worldName, ";", 3)
-		worldName = sp[0]
-		var offset world.ChunkPos
-		if len(sp) == 3 {
-			x, err := strconv.Atoi(sp[1])
-			if err != nil {
-				return fmt.Errorf("%s %w", worldName, err)
-			}
-			z, err := strconv.Atoi(sp[2])
-			if err != nil {
-				return fmt.Errorf("%s %w", worldName, err)
-			}
-			offset[0] = int32(x)
-			offset[1] = int32(z)
+	for _, arg := range c.f.Args() {
+		spec, err := parseInputSpec(arg)
+		if err != nil {
+			return err
 		}
+		worldName := spec.path
 		db, err := mcdb.Config{
 			Log:    slog.Default(),
 			Blocks: blockReg,
@@ -76,7 +75,15 @@ func (c *MergeCMD) Execute(ctx context.Context) error {
 			return fmt.Errorf("%s %w", worldName, err)
 		}
 		defer db.Close()
-		worlds = append(worlds, worldInstance{Name: worldName, db: db, offset: offset})
+		worlds = append(worlds, worldInstance{
+			inputSpec:  spec,
+			Name:       worldName,
+			db:         db,
+			lastPlayed: db.LevelDat().LastPlayed,
+		})
+	}
+	if len(worlds) == 0 {
+		return fmt.Errorf("no worlds to merge")
 	}
 
 	if c.showBounds {
@@ -106,15 +113,40 @@ func (c *MergeCMD) Execute(ctx context.Context) error {
 	}
 	defer dbOut.Close()
 
-	for _, w := range worlds {
-		err = c.processWorld(w.db, dbOut, w.offset)
+	airRID, _ := blockReg.StateToRuntimeID("minecraft:air", map[string]any{})
+	chunks, err := findChunks(worlds, policy == conflictMostBlocks, airRID)
+	if err != nil {
+		return err
+	}
+
+	report := overlapReport{
+		counts: make(map[string]int),
+		kept:   make(map[string]map[int]int),
+	}
+	winners := make(map[chunkKey]int, len(chunks))
+	for key, candidates := range chunks {
+		winner := pick(policy, worlds, candidates)
+		winners[key] = winner
+		if len(candidates) > 1 {
+			report.add(worlds, candidates, winner)
+		}
+	}
+	report.print(worlds)
+
+	for i, w := range worlds {
+		n, err := c.processWorld(i, w, dbOut, winners, airRID)
 		if err != nil {
 			return err
 		}
+		fmt.Printf("%s: %d chunks\n", w.Name, n)
 	}
 
-	ldat := worlds[0].db.LevelDat()
-	*dbOut.LevelDat() = *ldat
+	order := priority(policy, worlds)
+	err = mergeExtra(dbOut, worlds, order)
+	if err != nil {
+		return err
+	}
+	mergeLevelDat(dbOut, worlds, order)
 
 	return nil
 }
@@ -139,36 +171,114 @@ func (w worldInstance) getWorldBounds() (minChunk, maxChunk world.ChunkPos, err
 	return
 }
 
-func (c *MergeCMD) processWorld(db *mcdb.DB, out *mcdb.DB, offset world.ChunkPos) error {
-	it := db.NewColumnIterator(nil)
+// outputKey returns where a chunk of the input ends up in the output, false if it is filtered out
+func (w *worldInstance) outputKey(pos world.ChunkPos, dim world.Dimension) (chunkKey, bool) {
+	if !w.allows(pos) {
+		return chunkKey{}, false
+	}
+	id, _ := world.DimensionID(dim)
+	target, ok := w.targetDimension(id)
+	if !ok {
+		return chunkKey{}, false
+	}
+	return chunkKey{
+		dim: target,
+		pos: world.ChunkPos{pos[0] + w.offset[0], pos[1] + w.offset[1]},
+	}, true
+}
+
+// moved reports if chunks of dim have to be rebuilt to end up in the output
+func (w *worldInstance) moved(dim world.Dimension, key chunkKey) bool {
+	id, _ := world.DimensionID(dim)
+	return id != key.dim || w.yOffset != 0
+}
+
+// findChunks collects which inputs have each chunk of the output,
+// moved chunks are checked to fit into the height of the dimension they end up in
+func findChunks(worlds []worldInstance, withBlockCount bool, airRID uint32) (map[chunkKey][]candidate, error) {
+	chunks := make(map[chunkKey][]candidate)
+	for i, w := range worlds {
+		outOfRange := make(map[int]int)
+		it := w.db.NewColumnIterator(nil)
+		for it.Next() {
+			dim := it.Dimension()
+			key, ok := w.outputKey(it.Position(), dim)
+			if !ok {
+				continue
+			}
+			if w.moved(dim, key) {
+				target, _ := world.DimensionByID(key.dim)
+				if !fitsRange(it.Column().Chunk, target.Range(), w.yOffset, airRID) {
+					outOfRange[key.dim]++
+					continue
+				}
+			}
+			c := candidate{input: i}
+			if withBlockCount {
+				c.blocks = countBlocks(it.Column().Chunk, airRID)
+			}
+			chunks[key] = append(chunks[key], c)
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return nil, fmt.Errorf("%s %w", w.Name, err)
+		}
+		for dim, n := range outOfRange {
+			target, _ := world.DimensionByID(dim)
+			r := target.Range()
+			return nil, fmt.Errorf("%s: %d chunks have blocks outside of the %s height %d to %d with a y offset of %d",
+				w.Name, n, behaviourpack.DimensionName(dim), r[0], r[1], w.yOffset)
+		}
+	}
+	return chunks, nil
+}
+
+// processWorld stores the chunks of the input that won their conflicts
+func (c *MergeCMD) processWorld(input int, w worldInstance, out *mcdb.DB, winners map[chunkKey]int, airRID uint32) (n int, err error) {
+	it := w.db.NewColumnIterator(nil)
 	defer it.Release()
 	for it.Next() {
-		column := it.Column()
-		pos := it.Position()
 		dim := it.Dimension()
-		pos[0] += offset[0]
-		pos[1] += offset[1]
+		key, ok := w.outputKey(it.Position(), dim)
+		if !ok || winners[key] != input {
+			continue
+		}
+		column := it.Column()
+		target, _ := world.DimensionByID(key.dim)
+		if w.moved(dim, key) {
+			column.Chunk = moveChunk(column.Chunk, target.Range(), w.yOffset, airRID)
+		}
 		for _, ent := range column.Entities {
 			pos := ent.Data["Pos"].([]any)
 			x := pos[0].(float32)
 			y := pos[1].(float32)
 			z := pos[2].(float32)
 			ent.Data["Pos"] = []any{
-				x + float32(offset[0]*16),
-				y,
-				z + float32(offset[1]*16),
+				x + float32(w.offset[0]*16),
+				y + float32(w.yOffset),
+				z + float32(w.offset[1]*16),
 			}
 			ent.Data["UniqueID"] = rand.Int64()
 		}
-		err := out.StoreColumn(pos, dim, column)
+		shift := cube.Pos{int(w.offset[0]) * 16, w.yOffset, int(w.offset[1]) * 16}
+		for i, be := range column.BlockEntities {
+			pos := be.Pos.Add(shift)
+			be.Data["x"], be.Data["y"], be.Data["z"] = int32(pos[0]), int32(pos[1]), int32(pos[2])
+			column.BlockEntities[i].Pos = pos
+		}
+		for i := range column.ScheduledBlocks {
+			column.ScheduledBlocks[i].Pos = column.ScheduledBlocks[i].Pos.Add(shift)
+		}
+		err := out.StoreColumn(key.pos, target, column)
 		if err != nil {
-			return err
+			return n, err
 		}
+		n++
 	}
 	if err := it.Error(); err != nil {
-		return err
+		return n, err
 	}
-	return nil
+	return n, nil
 }
 
 func init() {
@@ -0,0 +1,289 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+)
+
+// parseRegion parses x1,z1,x2,z2 block coordinates, the result includes both corners
+func parseRegion(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("invalid region %q, expected x1,z1,x2,z2", s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		var err error
+		v[i], err = strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("invalid region %q: %w", s, err)
+		}
+	}
+	r := image.Rect(v[0], v[1], v[2], v[3]).Canon()
+	r.Max = r.Max.Add(image.Pt(1, 1))
+	return r, nil
+}
+
+// dimensionRegion returns the blocks covered by the chunks of one dimension
+func dimensionRegion(db *mcdb.DB, dim world.Dimension) (image.Rectangle, error) {
+	var region image.Rectangle
+	it := db.NewColumnIterator(nil)
+	for it.Next() {
+		if it.Dimension() != dim {
+			continue
+		}
+		pos := it.Position()
+		chunkRect := image.Rect(int(pos[0])*16, int(pos[1])*16, int(pos[0])*16+16, int(pos[1])*16+16)
+		region = region.Union(chunkRect)
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return image.Rectangle{}, err
+	}
+	if region.Empty() {
+		id, _ := world.DimensionID(dim)
+		return image.Rectangle{}, fmt.Errorf("world has no chunks in %s", behaviourpack.DimensionName(id))
+	}
+	return region, nil
+}
+
+// face of a block on screen
+const (
+	faceNone = iota
+	faceTop
+	faceLeft
+	faceRight
+)
+
+// isoSprite is the shape of one block, a is the half width of the block on screen
+type isoSprite struct {
+	a     int
+	faces []uint8
+}
+
+func newIsoSprite(a int) *isoSprite {
+	s := &isoSprite{a: a, faces: make([]uint8, 4*a*a)}
+	for py := range 2 * a {
+		for px := range 2 * a {
+			// distance from the center of the top face
+			dx := math.Abs(float64(px)+0.5-float64(a)) / float64(a)
+			dy := math.Abs(float64(py)+0.5-float64(a)/2) / (float64(a) / 2)
+			var face uint8
+			switch {
+			case dx+dy <= 1:
+				face = faceTop
+			case px < a && float64(py)+0.5 >= float64(a)/2+float64(px)/2 && float64(py)+0.5 <= float64(a)*1.5+float64(px)/2:
+				face = faceLeft
+			case px >= a && float64(py)+0.5 >= float64(a)/2+float64(2*a-px)/2 && float64(py)+0.5 <= float64(a)*1.5+float64(2*a-px)/2:
+				face = faceRight
+			}
+			s.faces[py*2*a+px] = face
+		}
+	}
+	return s
+}
+
+func (s *isoSprite) draw(img *image.RGBA, at image.Point, top color.RGBA) {
+	left := shade(top, 0.8)
+	right := shade(top, 0.6)
+	size := 2 * s.a
+	for py := range size {
+		for px := range size {
+			var c color.RGBA
+			switch s.faces[py*size+px] {
+			case faceTop:
+				c = top
+			case faceLeft:
+				c = left
+			case faceRight:
+				c = right
+			default:
+				continue
+			}
+			x, y := at.X+px, at.Y+py
+			if !(image.Point{x, y}.In(img.Rect)) {
+				continue
+			}
+			if c.A != 0xff {
+				c = utils.BlendColors(img.RGBAAt(x, y), c)
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func shade(c color.RGBA, f float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * f),
+		G: uint8(float64(c.G) * f),
+		B: uint8(float64(c.B) * f),
+		A: c.A,
+	}
+}
+
+// isoVolume gives access to the blocks of the region rotated around the y axis
+type isoVolume struct {
+	region   image.Rectangle
+	rotation int
+	columns  map[world.ChunkPos]*chunk.Chunk
+	blocks   world.BlockRegistry
+	renderer *utils.ChunkRenderer
+	air      map[uint32]bool
+}
+
+// size returns the size of the rotated region
+func (v *isoVolume) size() (int, int) {
+	w, d := v.region.Dx(), v.region.Dy()
+	if v.rotation%2 == 1 {
+		return d, w
+	}
+	return w, d
+}
+
+// worldPos turns rotated coordinates back into world x z
+func (v *isoVolume) worldPos(rx, rz int) (int, int) {
+	w, d := v.region.Dx(), v.region.Dy()
+	var lx, lz int
+	switch v.rotation {
+	case 0:
+		lx, lz = rx, rz
+	case 1:
+		lx, lz = rz, d-1-rx
+	case 2:
+		lx, lz = w-1-rx, d-1-rz
+	case 3:
+		lx, lz = w-1-rz, rx
+	}
+	return v.region.Min.X + lx, v.region.Min.Y + lz
+}
+
+func (v *isoVolume) column(rx, rz int) (*chunk.Chunk, uint8, uint8, bool) {
+	rw, rd := v.size()
+	if rx < 0 || rz < 0 || rx >= rw || rz >= rd {
+		return nil, 0, 0, false
+	}
+	x, z := v.worldPos(rx, rz)
+	c, ok := v.columns[world.ChunkPos{int32(x >> 4), int32(z >> 4)}]
+	return c, uint8(x & 15), uint8(z & 15), ok
+}
+
+func (v *isoVolume) isAir(rid uint32) bool {
+	air, ok := v.air[rid]
+	if !ok {
+		b, found := v.blocks.BlockByRuntimeID(rid)
+		_, air = b.(block.Air)
+		air = air || !found
+		v.air[rid] = air
+	}
+	return air
+}
+
+// faceVisible checks if the face of the block with rid towards the neighbour can be seen
+func (v *isoVolume) faceVisible(rid uint32, rx int, y int16, rz int) bool {
+	c, x, z, ok := v.column(rx, rz)
+	if !ok || y > int16(c.Range().Max()) {
+		return true
+	}
+	if y < int16(c.Range().Min()) {
+		return false
+	}
+	neighbour := c.Block(x, y, z, 0)
+	if v.isAir(neighbour) {
+		return true
+	}
+	if neighbour == rid {
+		return false
+	}
+	b, _ := v.blocks.BlockByRuntimeID(neighbour)
+	return v.renderer.BlockColor(b).A != 0xff
+}
+
+type isoBlock struct {
+	rx, rz int
+	y      int16
+	color  color.RGBA
+}
+
+// renderIsometric draws the region as seen from above at an angle, the blocks are sorted so nearer blocks are drawn last
+func renderIsometric(db *mcdb.DB, dim world.Dimension, blocks world.BlockRegistry, renderer *utils.ChunkRenderer, region image.Rectangle, rotation, scale int) (*image.RGBA, error) {
+	v := &isoVolume{
+		region:   region,
+		rotation: ((rotation % 4) + 4) % 4,
+		columns:  make(map[world.ChunkPos]*chunk.Chunk),
+		blocks:   blocks,
+		renderer: renderer,
+		air:      make(map[uint32]bool),
+	}
+
+	it := db.NewColumnIterator(&mcdb.IteratorRange{
+		Min:       world.ChunkPos{int32(region.Min.X >> 4), int32(region.Min.Y >> 4)},
+		Max:       world.ChunkPos{int32((region.Max.X-1)>>4) + 1, int32((region.Max.Y-1)>>4) + 1},
+		Dimension: dim,
+	})
+	for it.Next() {
+		v.columns[it.Position()] = it.Column().Chunk
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if len(v.columns) == 0 {
+		return nil, fmt.Errorf("no chunks in region %v", region)
+	}
+
+	var visible []isoBlock
+	minY, maxY := int16(math.MaxInt16), int16(math.MinInt16)
+	rw, rd := v.size()
+	for rx := range rw {
+		for rz := range rd {
+			c, x, z, ok := v.column(rx, rz)
+			if !ok {
+				continue
+			}
+			for y := int16(c.Range().Min()); y <= c.HighestBlock(x, z); y++ {
+				rid := c.Block(x, y, z, 0)
+				if v.isAir(rid) {
+					continue
+				}
+				// only the top and the faces towards +x and +z of the rotated region can be seen
+				if !v.faceVisible(rid, rx, y+1, rz) && !v.faceVisible(rid, rx+1, y, rz) && !v.faceVisible(rid, rx, y, rz+1) {
+					continue
+				}
+				b, _ := blocks.BlockByRuntimeID(rid)
+				visible = append(visible, isoBlock{rx: rx, rz: rz, y: y, color: renderer.BlockColor(b)})
+				minY, maxY = min(minY, y), max(maxY, y)
+			}
+		}
+	}
+	if len(visible) == 0 {
+		return nil, fmt.Errorf("region %v is empty", region)
+	}
+
+	// cubes with the same rx+y+rz dont overlap, so drawing in that order is back to front
+	slices.SortFunc(visible, func(a, b isoBlock) int {
+		return (a.rx + int(a.y) + a.rz) - (b.rx + int(b.y) + b.rz)
+	})
+
+	a := max(2, scale+scale%2)
+	sprite := newIsoSprite(a)
+	img := image.NewRGBA(image.Rect(0, 0, (rw+rd)*a, (rw+rd)*a/2+int(maxY-minY)*a+2*a))
+	for _, b := range visible {
+		sprite.draw(img, image.Pt(
+			(b.rx-b.rz)*a+(rd-1)*a,
+			(b.rx+b.rz)*a/2+int(maxY-b.y)*a,
+		), b.color)
+	}
+	return img, nil
+}
@@ -2,35 +2,42 @@ package render
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
 	"image/png"
-	"io/fs"
-	"log/slog"
 	"math"
 	"os"
 	"path"
 	"strings"
 
+	"github.com/bedrock-tool/bedrocktool/handlers/worlds/worldstate"
 	"github.com/bedrock-tool/bedrocktool/subcommands/merge"
 	"github.com/bedrock-tool/bedrocktool/utils"
 	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
 	"github.com/bedrock-tool/bedrocktool/utils/commands"
 	"github.com/df-mc/dragonfly/server/world"
-	"github.com/df-mc/dragonfly/server/world/mcdb"
-	"github.com/df-mc/goleveldb/leveldb/opt"
-	"github.com/sandertv/gophertunnel/minecraft/protocol"
-	"github.com/sandertv/gophertunnel/minecraft/resource"
+	_ "github.com/df-mc/dragonfly/server/world/biome"
+	"github.com/df-mc/dragonfly/server/world/chunk"
 	"github.com/sirupsen/logrus"
 )
 
 type RenderCMD struct {
-	WorldPath string
-	Out       string
+	WorldPath  string
+	Out        string
+	PlayerPath string
+	Mode       string
+	SliceY     int
+	Tiles      string
+	Dimension  string
+	Markers    string
+	Isometric  bool
+	Rotation   int
+	Region     string
+	Scale      int
+	NoCache    bool
 }
 
 func (*RenderCMD) Name() string     { return "render" }
@@ -39,109 +46,114 @@ func (*RenderCMD) Synopsis() string { return "render a world to png" }
 func (c *RenderCMD) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.WorldPath, "world", "", "world path")
 	f.StringVar(&c.Out, "out", "world.png", "out png path")
+	f.StringVar(&c.PlayerPath, "path", "", "player path (.geojson or .csv) to draw on top of the map")
+	f.StringVar(&c.Mode, "mode", "topdown", "render mode, one of "+strings.Join(utils.RenderModeNames(), ", "))
+	f.IntVar(&c.SliceY, "y", 64, "height to start from in the cave render mode")
+	f.StringVar(&c.Tiles, "tiles", "", "write a z/x/y tile pyramid with a html viewer to this folder instead of a single png")
+	f.StringVar(&c.Dimension, "dim", "overworld", "dimension to render with -tiles and -isometric, overworld, nether, end or an id")
+	f.StringVar(&c.Markers, "markers", "", "json file with markers to show in the tile viewer")
+	f.BoolVar(&c.Isometric, "isometric", false, "render an isometric view instead of a top down map")
+	f.IntVar(&c.Rotation, "rotation", 0, "isometric view direction, 0-3 in steps of 90 degrees")
+	f.StringVar(&c.Region, "region", "", "only render the blocks in x1,z1,x2,z2 for isometric renders")
+	f.IntVar(&c.Scale, "scale", 4, "isometric pixels per half block width")
+	f.BoolVar(&c.NoCache, "no-cache", false, "render all chunks instead of only the ones that changed since the last render")
 }
 
 func (c *RenderCMD) Execute(ctx context.Context) error {
+	mode, err := utils.ParseRenderMode(c.Mode)
+	if err != nil {
+		return err
+	}
+
 	blockReg := &merge.BlockRegistry{
 		BlockRegistry: world.DefaultBlockRegistry,
 		Rids:          make(map[uint32]merge.Block),
 	}
 
-	if c.WorldPath == "" {
-		var ok bool
-		c.WorldPath, ok = utils.UserInput(ctx, "World Path: ", func(s string) bool {
-			st, err := os.Stat(s)
-			if err != nil {
-				return false
-			}
-			return st.IsDir()
-		})
-		if !ok {
-			return nil
-		}
+	var ok bool
+	c.WorldPath, ok = behaviourpack.AskWorldPath(ctx, c.WorldPath)
+	if !ok {
+		return nil
 	}
 
-	c.WorldPath = path.Clean(strings.ReplaceAll(c.WorldPath, "\\", "/"))
 	c.Out = path.Clean(strings.ReplaceAll(c.Out, "\\", "/"))
 
-	if c.WorldPath == "" {
-		return fmt.Errorf("missing -world")
-	}
-
 	fmt.Printf("%s\n", c.WorldPath)
 
-	db, err := mcdb.Config{
-		Log:    slog.Default(),
-		Blocks: blockReg,
-		LDBOptions: &opt.Options{
-			ReadOnly: true,
-		},
-	}.Open(c.WorldPath)
+	db, err := behaviourpack.OpenWorld(c.WorldPath, blockReg, true)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	var resourcePacks []resource.Pack
-	resourcePacksFolder := path.Join(c.WorldPath, "resource_packs")
-	resourcePackEntries, err := os.ReadDir(resourcePacksFolder)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
+	resourcePacks, behaviorPacks, err := behaviourpack.ReadWorldPacks(c.WorldPath)
+	if err != nil {
+		return err
+	}
+	entries, err := behaviourpack.BlockEntries(behaviorPacks)
+	if err != nil {
 		return err
 	}
-	for _, entry := range resourcePackEntries {
-		pack, err := resource.ReadPath(path.Join(resourcePacksFolder, entry.Name()))
+
+	renderer := utils.ChunkRenderer{
+		Mode:   mode,
+		SliceY: int16(c.SliceY),
+		Biomes: world.DefaultBiomes,
+	}
+	renderer.ResolveColors(entries, resourcePacks)
+
+	renderChunk := func(dim world.Dimension, pos world.ChunkPos, ch *chunk.Chunk) *image.RGBA {
+		return renderer.Chunk2Img(ch)
+	}
+	if !c.NoCache {
+		cache, err := utils.OpenRenderCache(utils.RenderCacheFolder(c.WorldPath))
 		if err != nil {
 			return err
 		}
-		resourcePacks = append(resourcePacks, pack)
+		defer func() {
+			hits, misses := cache.Stats()
+			logrus.Infof("Rendered %d changed chunks, %d unchanged", misses, hits)
+			cache.Close()
+		}()
+		renderChunk = func(dim world.Dimension, pos world.ChunkPos, ch *chunk.Chunk) *image.RGBA {
+			id, _ := world.DimensionID(dim)
+			return cache.Chunk2Img(&renderer, id, pos, ch)
+		}
 	}
 
-	var behaviorPacks []resource.Pack
-	behaviorPacksFolder := path.Join(c.WorldPath, "behavior_packs")
-	behaviorPackEntries, err := os.ReadDir(behaviorPacksFolder)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
+	dimID, err := behaviourpack.ParseDimension(c.Dimension)
+	if err != nil {
 		return err
 	}
-	for _, entry := range behaviorPackEntries {
-		pack, err := resource.ReadPath(path.Join(behaviorPacksFolder, entry.Name()))
+	dim, _ := world.DimensionByID(dimID)
+
+	if c.Tiles != "" {
+		c.Tiles = path.Clean(strings.ReplaceAll(c.Tiles, "\\", "/"))
+		err = c.renderTiles(ctx, db, dim, renderChunk)
 		if err != nil {
 			return err
 		}
-		behaviorPacks = append(behaviorPacks, pack)
+		logrus.Infof("Wrote %s", path.Join(c.Tiles, "index.html"))
+		return nil
 	}
 
-	var entries []protocol.BlockEntry
-	for _, pack := range behaviorPacks {
-		blockEntries, err := fs.Glob(pack, "blocks/**/*.json")
+	if c.Isometric {
+		var region image.Rectangle
+		if c.Region != "" {
+			region, err = parseRegion(c.Region)
+		} else {
+			region, err = dimensionRegion(db, dim)
+		}
 		if err != nil {
 			return err
 		}
-		for _, bff := range blockEntries {
-			f, err := pack.Open(bff)
-			if err != nil {
-				return err
-			}
-			var BlockBehaviour behaviourpack.BlockBehaviour
-			err = json.NewDecoder(f).Decode(&BlockBehaviour)
-			f.Close()
-			if err != nil {
-				return err
-			}
-			block := BlockBehaviour.MinecraftBlock
-
-			ent := protocol.BlockEntry{
-				Name: block.Description.Identifier,
-				Properties: map[string]any{
-					"components": block.Components,
-				},
-			}
-			entries = append(entries, ent)
+		img, err := renderIsometric(db, dim, blockReg, &renderer, region, c.Rotation, c.Scale)
+		if err != nil {
+			return err
 		}
+		return writePNG(c.Out, img)
 	}
 
-	var renderer utils.ChunkRenderer
-	renderer.ResolveColors(entries, resourcePacks)
-
 	boundsMin := world.ChunkPos{math.MaxInt32, math.MaxInt32}
 	boundsMax := world.ChunkPos{math.MinInt32, math.MinInt32}
 	it := db.NewColumnIterator(nil)
@@ -171,7 +183,7 @@ func (c *RenderCMD) Execute(ctx context.Context) error {
 			break
 		}
 
-		tile := renderer.Chunk2Img(col.Chunk)
+		tile := renderChunk(it.Dimension(), pos, col.Chunk)
 		px := image.Pt(
 			int((pos.X()-boundsMin.X())*16),
 			int((pos.Z()-boundsMin.Z())*16),
@@ -186,7 +198,19 @@ func (c *RenderCMD) Execute(ctx context.Context) error {
 		return err
 	}
 
-	f, err := os.Create(c.Out)
+	if c.PlayerPath != "" {
+		playerPath, err := worldstate.ReadPlayerPath(c.PlayerPath)
+		if err != nil {
+			return err
+		}
+		drawPlayerPath(img, playerPath, boundsMin)
+	}
+
+	return writePNG(c.Out, img)
+}
+
+func writePNG(filename string, img image.Image) error {
+	f, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
@@ -196,11 +220,27 @@ func (c *RenderCMD) Execute(ctx context.Context) error {
 		return err
 	}
 
-	logrus.Infof("Wrote %s", c.Out)
-
+	logrus.Infof("Wrote %s", filename)
 	return nil
 }
 
+var pathColor = color.RGBA{R: 0xff, G: 0x20, B: 0x20, A: 0xff}
+
+// drawPlayerPath draws the path as lines onto the rendered image
+func drawPlayerPath(img *image.RGBA, playerPath *worldstate.PlayerPath, boundsMin world.ChunkPos) {
+	toPixel := func(p worldstate.PathPoint) image.Point {
+		return image.Pt(
+			int(math.Floor(float64(p.Position.X())))-int(boundsMin.X())*16,
+			int(math.Floor(float64(p.Position.Z())))-int(boundsMin.Z())*16,
+		)
+	}
+	for _, segment := range playerPath.Segments() {
+		for i := 1; i < len(segment); i++ {
+			utils.DrawLine(img, toPixel(segment[i-1]), toPixel(segment[i]), pathColor)
+		}
+	}
+}
+
 func init() {
 	commands.RegisterCommand(&RenderCMD{})
 }
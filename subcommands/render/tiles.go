@@ -0,0 +1,305 @@
+package render
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bedrock-tool/bedrocktool/handlers/worlds/worldstate"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/sirupsen/logrus"
+)
+
+// tileSize is the size of a tile in pixels, at the highest zoom level one pixel is one block
+const tileSize = 256
+
+const chunksPerTile = tileSize / 16
+
+//go:embed viewer.html
+var viewerHTML []byte
+
+// Marker is a point drawn on top of the tile map, only markers of the rendered dimension are shown
+type Marker struct {
+	Dimension int     `json:"dimension"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Z         float64 `json:"z"`
+	Label     string  `json:"label"`
+	Color     string  `json:"color,omitempty"`
+}
+
+// ReadMarkers reads a json array of markers
+func ReadMarkers(filename string) ([]Marker, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var markers []Marker
+	err = json.Unmarshal(data, &markers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return markers, nil
+}
+
+type columnRef struct {
+	pos world.ChunkPos
+	dim world.Dimension
+}
+
+// tileMapData is written to map.js for the viewer
+type tileMapData struct {
+	MaxZoom  int            `json:"maxZoom"`
+	TileSize int            `json:"tileSize"`
+	Min      [2]int32       `json:"min"`
+	Max      [2]int32       `json:"max"`
+	Path     [][][2]float32 `json:"path"`
+	Markers  []Marker       `json:"markers"`
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func tilePath(outDir string, z int, tile image.Point) string {
+	return path.Join(outDir, fmt.Sprint(z), fmt.Sprint(tile.X), fmt.Sprintf("%d.png", tile.Y))
+}
+
+func writeTile(filename string, img *image.RGBA) error {
+	err := os.MkdirAll(path.Dir(filename), 0o755)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+func readTile(filename string) (*image.RGBA, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba, nil
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
+
+// downscaleInto averages 2x2 pixels of src into one quarter of dst
+func downscaleInto(dst, src *image.RGBA, offset image.Point) {
+	for y := 0; y < tileSize/2; y++ {
+		for x := 0; x < tileSize/2; x++ {
+			var sum [4]int
+			for _, d := range [4]image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				i := src.PixOffset(x*2+d.X, y*2+d.Y)
+				for c := 0; c < 4; c++ {
+					sum[c] += int(src.Pix[i+c])
+				}
+			}
+			i := dst.PixOffset(offset.X+x, offset.Y+y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8(sum[c] / 4)
+			}
+		}
+	}
+}
+
+// parallel runs fn for every item on all cpus, returning the first error
+func parallel[T comparable](ctx context.Context, items map[T]struct{}, fn func(T) error) error {
+	work := make(chan T)
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	var errOnce sync.Once
+	var firstErr error
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				if err := fn(item); err != nil {
+					errOnce.Do(func() { firstErr = err })
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	for item := range items {
+		if ctx.Err() != nil || failed.Load() {
+			break
+		}
+		work <- item
+	}
+	close(work)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// renderTiles writes a z/x/y tile pyramid of one dimension of the world with a viewer to outDir
+func (c *RenderCMD) renderTiles(ctx context.Context, db *mcdb.DB, dim world.Dimension, renderChunk func(world.Dimension, world.ChunkPos, *chunk.Chunk) *image.RGBA) error {
+	// group the columns by the tile they are on at the highest zoom
+	tiles := make(map[image.Point][]columnRef)
+	boundsMin := world.ChunkPos{math.MaxInt32, math.MaxInt32}
+	boundsMax := world.ChunkPos{math.MinInt32, math.MinInt32}
+	it := db.NewColumnIterator(nil)
+	for it.Next() {
+		if it.Dimension() != dim {
+			continue
+		}
+		pos := it.Position()
+		tile := image.Pt(floorDiv(int(pos[0]), chunksPerTile), floorDiv(int(pos[1]), chunksPerTile))
+		tiles[tile] = append(tiles[tile], columnRef{pos: pos, dim: it.Dimension()})
+		boundsMin[0] = min(boundsMin[0], pos[0])
+		boundsMin[1] = min(boundsMin[1], pos[1])
+		boundsMax[0] = max(boundsMax[0], pos[0])
+		boundsMax[1] = max(boundsMax[1], pos[1])
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if len(tiles) == 0 {
+		return fmt.Errorf("world has no chunks in %s", c.Dimension)
+	}
+
+	// zoom out until the world fits on one tile
+	var maxZoom int
+	tileMin := image.Pt(floorDiv(int(boundsMin[0]), chunksPerTile), floorDiv(int(boundsMin[1]), chunksPerTile))
+	tileMax := image.Pt(floorDiv(int(boundsMax[0]), chunksPerTile), floorDiv(int(boundsMax[1]), chunksPerTile))
+	for size := max(tileMax.X-tileMin.X, tileMax.Y-tileMin.Y) + 1; size > 1; size = (size + 1) / 2 {
+		maxZoom++
+	}
+	logrus.Infof("Rendering %d tiles, %d zoom levels", len(tiles), maxZoom+1)
+
+	level := make(map[image.Point]struct{}, len(tiles))
+	for tile := range tiles {
+		level[tile] = struct{}{}
+	}
+	err := parallel(ctx, level, func(tile image.Point) error {
+		img := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+		for _, ref := range tiles[tile] {
+			col, err := db.LoadColumn(ref.pos, ref.dim)
+			if err != nil {
+				return fmt.Errorf("chunk %v: %w", ref.pos, err)
+			}
+			px := image.Pt(
+				(int(ref.pos[0])-tile.X*chunksPerTile)*16,
+				(int(ref.pos[1])-tile.Y*chunksPerTile)*16,
+			)
+			draw.Draw(img, image.Rect(px.X, px.Y, px.X+16, px.Y+16), renderChunk(ref.dim, ref.pos, col.Chunk), image.Point{}, draw.Src)
+		}
+		return writeTile(tilePath(c.Tiles, maxZoom, tile), img)
+	})
+	if err != nil {
+		return err
+	}
+
+	// every lower zoom level is built from the 4 tiles of the level above
+	for z := maxZoom - 1; z >= 0; z-- {
+		above := level
+		level = make(map[image.Point]struct{})
+		for tile := range above {
+			level[image.Pt(floorDiv(tile.X, 2), floorDiv(tile.Y, 2))] = struct{}{}
+		}
+		err := parallel(ctx, level, func(tile image.Point) error {
+			img := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+			for dy := range 2 {
+				for dx := range 2 {
+					child := image.Pt(tile.X*2+dx, tile.Y*2+dy)
+					if _, ok := above[child]; !ok {
+						continue
+					}
+					childImg, err := readTile(tilePath(c.Tiles, z+1, child))
+					if err != nil {
+						return err
+					}
+					downscaleInto(img, childImg, image.Pt(dx*tileSize/2, dy*tileSize/2))
+				}
+			}
+			return writeTile(tilePath(c.Tiles, z, tile), img)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	data := tileMapData{
+		MaxZoom:  maxZoom,
+		TileSize: tileSize,
+		Min:      [2]int32{boundsMin[0] * 16, boundsMin[1] * 16},
+		Max:      [2]int32{boundsMax[0]*16 + 16, boundsMax[1]*16 + 16},
+		Path:     [][][2]float32{},
+		Markers:  []Marker{},
+	}
+	dimID, _ := world.DimensionID(dim)
+	if c.PlayerPath != "" {
+		playerPath, err := worldstate.ReadPlayerPath(c.PlayerPath)
+		if err != nil {
+			return err
+		}
+		for _, segment := range playerPath.Segments() {
+			if segment[0].Dimension != dimID {
+				continue
+			}
+			var line [][2]float32
+			for _, p := range segment {
+				line = append(line, [2]float32{p.Position.X(), p.Position.Z()})
+			}
+			data.Path = append(data.Path, line)
+		}
+	}
+	if c.Markers != "" {
+		markers, err := ReadMarkers(c.Markers)
+		if err != nil {
+			return err
+		}
+		for _, m := range markers {
+			if m.Dimension == dimID {
+				data.Markers = append(data.Markers, m)
+			}
+		}
+	}
+	return writeViewer(c.Tiles, &data)
+}
+
+// writeViewer writes index.html and the map data as a script, so it can be opened without a web server
+func writeViewer(outDir string, data *tileMapData) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path.Join(outDir, "map.js"), append(append([]byte("var mapData = "), js...), ";\n"...), 0o644)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(outDir, "index.html"), viewerHTML, 0o644)
+}
@@ -6,7 +6,9 @@ import (
 	"os"
 	"strings"
 
+	"github.com/bedrock-tool/bedrocktool/handlers"
 	"github.com/bedrock-tool/bedrocktool/handlers/worlds"
+	"github.com/bedrock-tool/bedrocktool/handlers/worlds/worldstate"
 	"github.com/bedrock-tool/bedrocktool/locale"
 	"github.com/bedrock-tool/bedrocktool/utils"
 	"github.com/bedrock-tool/bedrocktool/utils/commands"
@@ -27,6 +29,12 @@ type WorldCMD struct {
 	ChunkRadius       int
 	ScriptPath        string
 	EnableClientCache bool
+	ExtractText       bool
+	ExportItems       bool
+	Spawn             string
+	Weather           string
+	Difficulty        string
+	GameMode          string
 }
 
 func (*WorldCMD) Name() string     { return "worlds" }
@@ -46,6 +54,12 @@ func (c *WorldCMD) SetFlags(f *flag.FlagSet) {
 	f.IntVar(&c.ChunkRadius, "chunk-radius", 0, "the max chunk radius to force")
 	f.StringVar(&c.ScriptPath, "script", "", "path to script to use")
 	f.BoolVar(&c.EnableClientCache, "client-cache", true, "Enable Client Cache")
+	f.BoolVar(&c.ExtractText, "extract-text", false, "export sign, lectern and book text next to the saved world")
+	f.BoolVar(&c.ExportItems, "items", false, "export the creative item catalogue of the server")
+	f.StringVar(&c.Spawn, "spawn", "", "world spawn to save, x,y,z or player (default is the servers world spawn)")
+	f.StringVar(&c.Weather, "weather", "", "weather to save, clear, rain or thunder (default is the servers weather)")
+	f.StringVar(&c.Difficulty, "difficulty", "", "difficulty to save, peaceful, easy, normal or hard (default is the servers difficulty)")
+	f.StringVar(&c.GameMode, "gamemode", "", "default game mode to save, survival, creative, adventure or spectator (default is the servers game mode)")
 }
 
 func (c *WorldCMD) Execute(ctx context.Context) error {
@@ -58,6 +72,11 @@ func (c *WorldCMD) Execute(ctx context.Context) error {
 		script = string(data)
 	}
 
+	levelOverrides, spawnAtPlayer, err := worldstate.ParseLevelOverrides(c.Spawn, c.Weather, c.Difficulty, c.GameMode)
+	if err != nil {
+		return err
+	}
+
 	proxy, err := proxy.New(ctx, true, c.EnableClientCache)
 	if err != nil {
 		return err
@@ -75,8 +94,15 @@ func (c *WorldCMD) Execute(ctx context.Context) error {
 		ChunkRadius:     int32(c.ChunkRadius),
 		Script:          script,
 		BlockUpdates:    c.BlockUpdates,
+		ExtractText:     c.ExtractText,
+		LevelOverrides:  levelOverrides,
+		SpawnAtPlayer:   spawnAtPlayer,
 	}))
 
+	if c.ExportItems {
+		proxy.AddHandler(handlers.NewItemCatalogue())
+	}
+
 	server := ctx.Value(utils.ConnectInfoKey).(*utils.ConnectInfo)
 	err = proxy.Run(server)
 	if err != nil {
@@ -0,0 +1,68 @@
+package worldinventory
+
+import (
+	"context"
+	"flag"
+	"path"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/subcommands/merge"
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/worldinventory"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sirupsen/logrus"
+)
+
+type WorldInventoryCMD struct {
+	WorldPath string
+	Out       string
+}
+
+func (*WorldInventoryCMD) Name() string { return "world-inventory-export" }
+func (*WorldInventoryCMD) Synopsis() string {
+	return "export the contents of the containers in a world"
+}
+
+func (c *WorldInventoryCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.WorldPath, "world", "", "world path")
+	f.StringVar(&c.Out, "out", "inventory.json", "output path, .json or .csv")
+}
+
+func (c *WorldInventoryCMD) Execute(ctx context.Context) error {
+	blockReg := &merge.BlockRegistry{
+		BlockRegistry: world.DefaultBlockRegistry,
+		Rids:          make(map[uint32]merge.Block),
+	}
+
+	var ok bool
+	c.WorldPath, ok = behaviourpack.AskWorldPath(ctx, c.WorldPath)
+	if !ok {
+		return nil
+	}
+
+	c.Out = path.Clean(strings.ReplaceAll(c.Out, "\\", "/"))
+
+	db, err := behaviourpack.OpenWorld(c.WorldPath, blockReg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := worldinventory.FromDB(db)
+	if err != nil {
+		return err
+	}
+
+	err = worldinventory.WriteFile(c.Out, rows)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Wrote %d items to %s", len(rows), c.Out)
+	return nil
+}
+
+func init() {
+	commands.RegisterCommand(&WorldInventoryCMD{})
+}
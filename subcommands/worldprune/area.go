@@ -0,0 +1,78 @@
+package worldprune
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// area is the part of the world that is kept, in block coordinates
+type area interface {
+	contains(x, z float64) bool
+}
+
+type box struct {
+	minX, minZ, maxX, maxZ float64
+}
+
+func (b box) contains(x, z float64) bool {
+	return x >= b.minX && x <= b.maxX && z >= b.minZ && z <= b.maxZ
+}
+
+type polygon [][2]float64
+
+// contains uses ray casting, points on the edge may be inside or outside
+func (p polygon) contains(x, z float64) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a[1] > z) != (b[1] > z) && x < (b[0]-a[0])*(z-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func parseNumbers(s string) ([]float64, error) {
+	var out []float64
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// parseBox parses x1,z1,x2,z2
+func parseBox(s string) (area, error) {
+	v, err := parseNumbers(s)
+	if err != nil || len(v) != 4 {
+		return nil, fmt.Errorf("invalid box %q, expected x1,z1,x2,z2", s)
+	}
+	return box{min(v[0], v[2]), min(v[1], v[3]), max(v[0], v[2]), max(v[1], v[3])}, nil
+}
+
+// parsePolygon parses x1,z1;x2,z2;x3,z3...
+func parsePolygon(s string) (area, error) {
+	var p polygon
+	for _, point := range strings.Split(s, ";") {
+		v, err := parseNumbers(point)
+		if err != nil || len(v) != 2 {
+			return nil, fmt.Errorf("invalid polygon point %q, expected x,z", point)
+		}
+		p = append(p, [2]float64{v[0], v[1]})
+	}
+	if len(p) < 3 {
+		return nil, fmt.Errorf("a polygon needs at least 3 points")
+	}
+	return p, nil
+}
+
+// columnInside checks if the center of a chunk is inside the area
+func columnInside(a area, pos world.ChunkPos) bool {
+	return a.contains(float64(pos[0])*16+8, float64(pos[1])*16+8)
+}
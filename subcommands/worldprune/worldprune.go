@@ -0,0 +1,271 @@
+package worldprune
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/subcommands/merge"
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/dbkeys"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/df-mc/goleveldb/leveldb/util"
+	"github.com/sirupsen/logrus"
+)
+
+type WorldPruneCMD struct {
+	WorldPath  string
+	Box        string
+	Polygon    string
+	Air        bool
+	Incomplete bool
+	DryRun     bool
+	Yes        bool
+}
+
+func (*WorldPruneCMD) Name() string { return "world-prune" }
+func (*WorldPruneCMD) Synopsis() string {
+	return "delete empty, incomplete and out of bounds chunks from a world"
+}
+
+func (c *WorldPruneCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.WorldPath, "world", "", "world path")
+	f.StringVar(&c.Box, "box", "", "delete chunks outside of the block area x1,z1,x2,z2")
+	f.StringVar(&c.Polygon, "polygon", "", "delete chunks outside of the block polygon x1,z1;x2,z2;x3,z3...")
+	f.BoolVar(&c.Air, "air", true, "delete chunks that only have air")
+	f.BoolVar(&c.Incomplete, "incomplete", false, "delete chunks with fewer sub chunks than their dimension has, only for worlds saved by bedrocktool since the game leaves out empty sub chunks")
+	f.BoolVar(&c.DryRun, "dry-run", false, "only show what would be deleted")
+	f.BoolVar(&c.Yes, "yes", false, "delete without asking after showing what will be deleted")
+}
+
+type pruneReason string
+
+const (
+	reasonOutside    pruneReason = "outside"
+	reasonIncomplete pruneReason = "incomplete"
+	reasonAir        pruneReason = "only air"
+)
+
+type columnID struct {
+	dim int
+	pos world.ChunkPos
+}
+
+// column are the keys that belong to a chunk
+type column struct {
+	keys      [][]byte
+	subChunks int
+	entityIDs []int64
+	reason    pruneReason
+}
+
+func (c *WorldPruneCMD) Execute(ctx context.Context) error {
+	var keep []area
+	if c.Box != "" {
+		a, err := parseBox(c.Box)
+		if err != nil {
+			return err
+		}
+		keep = append(keep, a)
+	}
+	if c.Polygon != "" {
+		a, err := parsePolygon(c.Polygon)
+		if err != nil {
+			return err
+		}
+		keep = append(keep, a)
+	}
+
+	blockReg := &merge.BlockRegistry{
+		BlockRegistry: world.DefaultBlockRegistry,
+		Rids:          make(map[uint32]merge.Block),
+	}
+
+	var ok bool
+	c.WorldPath, ok = behaviourpack.AskWorldPath(ctx, c.WorldPath)
+	if !ok {
+		return nil
+	}
+
+	db, err := behaviourpack.OpenWorld(c.WorldPath, blockReg, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	columns, err := readColumns(db.LDB())
+	if err != nil {
+		return err
+	}
+
+	airRID, _ := blockReg.StateToRuntimeID("minecraft:air", map[string]any{})
+	counts := make(map[string]map[pruneReason]int)
+	var pruned int
+	for id, col := range columns {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		col.reason, err = c.reason(db, keep, id, col, airRID)
+		if err != nil {
+			return err
+		}
+		if col.reason == "" {
+			continue
+		}
+		name := behaviourpack.DimensionName(id.dim)
+		if counts[name] == nil {
+			counts[name] = make(map[pruneReason]int)
+		}
+		counts[name][col.reason]++
+		pruned++
+	}
+
+	fmt.Printf("%d of %d chunks to delete\n", pruned, len(columns))
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		for _, reason := range []pruneReason{reasonOutside, reasonIncomplete, reasonAir} {
+			if n := counts[name][reason]; n > 0 {
+				fmt.Printf("  %s %s: %d\n", name, reason, n)
+			}
+		}
+	}
+	if c.DryRun || pruned == 0 {
+		return nil
+	}
+	if !c.Yes {
+		answer, cancelled := utils.UserInput(ctx, "Delete these chunks? [y/N]: ", nil)
+		if cancelled || !slices.Contains([]string{"y", "yes"}, strings.ToLower(strings.TrimSpace(answer))) {
+			logrus.Info("Nothing deleted")
+			return nil
+		}
+	}
+
+	err = deleteColumns(db.LDB(), columns)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Deleted %d chunks, compacting", pruned)
+	return db.LDB().CompactRange(util.Range{})
+}
+
+// readColumns groups all chunk keys and entity lists of the database by column
+func readColumns(ldb *leveldb.DB) (map[columnID]*column, error) {
+	columns := make(map[columnID]*column)
+	get := func(id columnID) *column {
+		col, ok := columns[id]
+		if !ok {
+			col = &column{}
+			columns[id] = col
+		}
+		return col
+	}
+
+	it := ldb.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		if k, ok := dbkeys.ParseChunkKey(key); ok {
+			col := get(columnID{k.Dimension, k.Pos})
+			col.keys = append(col.keys, slices.Clone(key))
+			if k.Tag == dbkeys.TagSubChunk {
+				col.subChunks++
+			}
+			continue
+		}
+		if pos, dim, ok := dbkeys.ParseEntityIDsKey(key); ok {
+			col := get(columnID{dim, pos})
+			col.keys = append(col.keys, slices.Clone(key))
+			col.entityIDs = dbkeys.EntityIDs(it.Value())
+		}
+	}
+	return columns, it.Error()
+}
+
+// reason returns why a column should be deleted, empty if it is kept
+func (c *WorldPruneCMD) reason(db *mcdb.DB, keep []area, id columnID, col *column, airRID uint32) (pruneReason, error) {
+	for _, a := range keep {
+		if !columnInside(a, id.pos) {
+			return reasonOutside, nil
+		}
+	}
+
+	dim, ok := world.DimensionByID(id.dim)
+	if !ok {
+		return "", nil
+	}
+	if c.Incomplete && col.subChunks < dim.Range().Height()>>4+1 {
+		return reasonIncomplete, nil
+	}
+	if c.Air {
+		loaded, err := db.LoadColumn(id.pos, dim)
+		if err != nil {
+			return "", fmt.Errorf("chunk %v: %w", id.pos, err)
+		}
+		if len(loaded.Entities) == 0 && len(loaded.BlockEntities) == 0 && onlyAir(loaded.Chunk, airRID) {
+			return reasonAir, nil
+		}
+	}
+	return "", nil
+}
+
+func onlyAir(c *chunk.Chunk, airRID uint32) bool {
+	for _, sub := range c.Sub() {
+		if sub.Empty() {
+			continue
+		}
+		for _, layer := range sub.Layers() {
+			p := layer.Palette()
+			for i := 0; i < p.Len(); i++ {
+				if p.Value(uint16(i)) == airRID {
+					continue
+				}
+				// palettes can keep blocks that are no longer used
+				for x := byte(0); x < 16; x++ {
+					for y := byte(0); y < 16; y++ {
+						for z := byte(0); z < 16; z++ {
+							if layer.At(x, y, z) != airRID {
+								return false
+							}
+						}
+					}
+				}
+				break
+			}
+		}
+	}
+	return true
+}
+
+// deleteColumns removes all keys and entities of the pruned columns
+func deleteColumns(ldb *leveldb.DB, columns map[columnID]*column) error {
+	batch := new(leveldb.Batch)
+	for _, col := range columns {
+		if col.reason == "" {
+			continue
+		}
+		for _, key := range col.keys {
+			batch.Delete(key)
+		}
+		for _, id := range col.entityIDs {
+			batch.Delete(dbkeys.EntityKey(id))
+		}
+		if batch.Len() > 4096 {
+			if err := ldb.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return ldb.Write(batch, nil)
+}
+
+func init() {
+	commands.RegisterCommand(&WorldPruneCMD{})
+}
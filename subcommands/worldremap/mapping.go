@@ -0,0 +1,87 @@
+package worldremap
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+)
+
+// Mapping replaces the custom blocks matching a glob pattern with a vanilla block
+type Mapping struct {
+	// Match is a glob pattern of custom block names, for example myserver:*_planks
+	Match string `json:"match"`
+	// Block is the vanilla block to use
+	Block string `json:"block"`
+	// States are set on the vanilla block
+	States map[string]any `json:"states,omitempty"`
+	// Properties copies properties of the custom block to states of the vanilla block, custom name to vanilla name
+	Properties map[string]string `json:"properties,omitempty"`
+	// Values converts the values of copied properties, property name to custom value to vanilla value
+	Values map[string]map[string]any `json:"values,omitempty"`
+}
+
+// ReadMappings reads a json array of mappings
+func ReadMappings(filename string) ([]Mapping, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var mappings []Mapping
+	err = json.Unmarshal(data, &mappings)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	for _, m := range mappings {
+		if _, err := path.Match(m.Match, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern %q: %w", filename, m.Match, err)
+		}
+		if m.Block == "" {
+			return nil, fmt.Errorf("%s: mapping for %q has no block", filename, m.Match)
+		}
+	}
+	return mappings, nil
+}
+
+// stateValue converts a json value to the type block states use
+func stateValue(v any) any {
+	switch v := v.(type) {
+	case float64:
+		return int32(v)
+	case bool:
+		if v {
+			return uint8(1)
+		}
+		return uint8(0)
+	}
+	return v
+}
+
+// apply returns the vanilla state for a custom block, false if the mapping doesnt match it.
+// states that the mapping doesnt set keep the default of the vanilla block from defaults
+func (m *Mapping) apply(name string, properties map[string]any, defaults map[string]map[string]any) (string, map[string]any, bool) {
+	if ok, _ := path.Match(m.Match, name); !ok {
+		return "", nil, false
+	}
+	states := maps.Clone(defaults[m.Block])
+	if states == nil {
+		states = make(map[string]any, len(m.States)+len(m.Properties))
+	}
+	for k, v := range m.States {
+		states[k] = stateValue(v)
+	}
+	for from, to := range m.Properties {
+		v, ok := properties[from]
+		if !ok {
+			continue
+		}
+		if values, ok := m.Values[from]; ok {
+			if converted, ok := values[fmt.Sprint(v)]; ok {
+				v = stateValue(converted)
+			}
+		}
+		states[to] = v
+	}
+	return m.Block, states, true
+}
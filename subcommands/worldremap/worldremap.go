@@ -0,0 +1,289 @@
+package worldremap
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image/color"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/subcommands/merge"
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/df-mc/dragonfly/server/block/model"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/sirupsen/logrus"
+)
+
+type WorldRemapCMD struct {
+	WorldPath string
+	Out       string
+	Mapping   string
+	Closest   bool
+}
+
+func (*WorldRemapCMD) Name() string { return "world-remap" }
+func (*WorldRemapCMD) Synopsis() string {
+	return "replace the custom blocks of a world with vanilla blocks"
+}
+
+func (c *WorldRemapCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.WorldPath, "world", "", "world path")
+	f.StringVar(&c.Out, "out", "", "folder to write the remapped world to")
+	f.StringVar(&c.Mapping, "mapping", "", "json file with the mappings from custom to vanilla blocks")
+	f.BoolVar(&c.Closest, "closest", false, "replace custom blocks without a mapping with the vanilla block of the closest colour")
+}
+
+// remapper decides the vanilla block for every custom block runtime id
+type remapper struct {
+	blocks   *merge.BlockRegistry
+	mappings []Mapping
+	colors   map[string]color.RGBA
+	closest  bool
+
+	// vanilla is the list of full blocks to pick the closest colour from
+	vanilla []vanillaColor
+	// defaults are the default states of the vanilla blocks by name
+	defaults map[string]map[string]any
+	rids     map[uint32]uint32
+	// remapped is what each replaced custom block became, kept are the ones that stayed
+	remapped map[string]string
+	kept     map[string]bool
+}
+
+type vanillaColor struct {
+	rid uint32
+	c   color.RGBA
+}
+
+func (c *WorldRemapCMD) Execute(ctx context.Context) error {
+	if c.Out == "" {
+		return fmt.Errorf("-out must be specified")
+	}
+	if c.Mapping == "" && !c.Closest {
+		return fmt.Errorf("nothing to do, use -mapping or -closest")
+	}
+
+	blockReg := &merge.BlockRegistry{
+		BlockRegistry: world.DefaultBlockRegistry,
+		Rids:          make(map[uint32]merge.Block),
+	}
+
+	r := &remapper{
+		blocks:   blockReg,
+		closest:  c.Closest,
+		rids:     make(map[uint32]uint32),
+		remapped: make(map[string]string),
+		kept:     make(map[string]bool),
+	}
+	if c.Mapping != "" {
+		var err error
+		r.mappings, err = ReadMappings(c.Mapping)
+		if err != nil {
+			return err
+		}
+		r.defaults = vanillaDefaults(blockReg.BlockRegistry)
+	}
+
+	var ok bool
+	c.WorldPath, ok = behaviourpack.AskWorldPath(ctx, c.WorldPath)
+	if !ok {
+		return nil
+	}
+
+	c.Out = path.Clean(strings.ReplaceAll(c.Out, "\\", "/"))
+	if c.WorldPath == c.Out {
+		return fmt.Errorf("-out has to be a different folder than the world")
+	}
+
+	if c.Closest {
+		resourcePacks, behaviorPacks, err := behaviourpack.ReadWorldPacks(c.WorldPath)
+		if err != nil {
+			return err
+		}
+		entries, err := behaviourpack.BlockEntries(behaviorPacks)
+		if err != nil {
+			return err
+		}
+		r.colors = utils.ResolveColors(entries, resourcePacks)
+		r.vanilla = vanillaColors(blockReg.BlockRegistry)
+	}
+
+	logrus.Infof("Copying %s to %s", c.WorldPath, c.Out)
+	err := utils.CopyFS(os.DirFS(c.WorldPath), utils.OSWriter{Base: c.Out})
+	if err != nil {
+		return err
+	}
+
+	db, err := behaviourpack.OpenWorld(c.Out, blockReg, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var n int
+	it := db.NewColumnIterator(nil)
+	for it.Next() {
+		if ctx.Err() != nil {
+			break
+		}
+		col := it.Column()
+		if !r.remapChunk(col.Chunk) {
+			continue
+		}
+		err = db.StoreColumn(it.Position(), it.Dimension(), col)
+		if err != nil {
+			it.Release()
+			return err
+		}
+		n++
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(r.remapped)) {
+		fmt.Printf("%s -> %s\n", name, r.remapped[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(r.kept)) {
+		fmt.Printf("%s has no mapping, kept\n", name)
+	}
+	logrus.Infof("Remapped %d custom blocks in %d chunks", len(r.remapped), n)
+	if len(r.kept) == 0 {
+		logrus.Info("No custom blocks are left, the behaviour pack is only needed for items and entities now")
+	}
+	return nil
+}
+
+// vanillaDefaults returns the states of the first runtime id of every vanilla block,
+// which is the state dragonfly uses as the default
+func vanillaDefaults(vanilla world.BlockRegistry) map[string]map[string]any {
+	out := make(map[string]map[string]any)
+	for rid := range uint32(vanilla.BlockCount()) {
+		name, properties, ok := vanilla.RuntimeIDToState(rid)
+		if !ok {
+			continue
+		}
+		if _, ok := out[name]; !ok {
+			out[name] = properties
+		}
+	}
+	return out
+}
+
+// vanillaColors returns the colours of all full vanilla blocks
+func vanillaColors(vanilla world.BlockRegistry) []vanillaColor {
+	var out []vanillaColor
+	for rid := range uint32(vanilla.BlockCount()) {
+		b, ok := vanilla.BlockByRuntimeID(rid)
+		if !ok {
+			continue
+		}
+		if _, solid := b.Model().(model.Solid); !solid {
+			continue
+		}
+		c := b.Color()
+		// magenta is the colour of blocks without one
+		if c.A != 0xff || (c.R == 0xff && c.G == 0 && c.B == 0xff) {
+			continue
+		}
+		out = append(out, vanillaColor{rid: rid, c: c})
+	}
+	return out
+}
+
+func colorDistance(a, b color.RGBA) int {
+	dr := int(a.R) - int(b.R)
+	dg := int(a.G) - int(b.G)
+	db := int(a.B) - int(b.B)
+	return dr*dr + dg*dg + db*db
+}
+
+func (r *remapper) closestBlock(c color.RGBA) uint32 {
+	best, bestDistance := r.vanilla[0].rid, -1
+	for _, v := range r.vanilla {
+		if d := colorDistance(c, v.c); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = v.rid, d
+		}
+	}
+	return best
+}
+
+// runtimeID returns the runtime id a block is replaced with, the same one if it stays
+func (r *remapper) runtimeID(rid uint32) uint32 {
+	if to, ok := r.rids[rid]; ok {
+		return to
+	}
+	to := r.resolve(rid)
+	r.rids[rid] = to
+	return to
+}
+
+func (r *remapper) resolve(rid uint32) uint32 {
+	vanilla := r.blocks.BlockRegistry
+	if _, ok := vanilla.BlockByRuntimeID(rid); ok {
+		return rid
+	}
+	name, properties, _ := r.blocks.RuntimeIDToState(rid)
+
+	for _, m := range r.mappings {
+		toName, toStates, ok := m.apply(name, properties, r.defaults)
+		if !ok {
+			continue
+		}
+		to, found := vanilla.StateToRuntimeID(toName, toStates)
+		if !found {
+			logrus.Warnf("%s %v: %s %v is not a vanilla block state", name, properties, toName, toStates)
+			break
+		}
+		r.remapped[name] = toName
+		return to
+	}
+
+	if c, ok := r.colors[name]; ok && r.closest && len(r.vanilla) > 0 {
+		to := r.closestBlock(c)
+		toName, _, _ := vanilla.RuntimeIDToState(to)
+		r.remapped[name] = toName + " (closest colour)"
+		return to
+	}
+	r.kept[name] = true
+	return rid
+}
+
+// remapChunk replaces the custom blocks in all palettes of a chunk, false if nothing changed
+func (r *remapper) remapChunk(c *chunk.Chunk) bool {
+	var changed bool
+	for _, sub := range c.Sub() {
+		if sub.Empty() {
+			continue
+		}
+		for _, layer := range sub.Layers() {
+			layer.Palette().Replace(func(rid uint32) uint32 {
+				to := r.runtimeID(rid)
+				if to != rid {
+					changed = true
+				}
+				return to
+			})
+		}
+	}
+	if changed {
+		// multiple custom blocks can become the same vanilla block
+		c.Compact()
+	}
+	return changed
+}
+
+func init() {
+	commands.RegisterCommand(&WorldRemapCMD{})
+}
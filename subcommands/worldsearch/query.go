@@ -0,0 +1,69 @@
+package worldsearch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// withNamespace adds minecraft: to identifiers that have no namespace
+func withNamespace(name string) string {
+	if name == "" || strings.Contains(name, ":") {
+		return name
+	}
+	return "minecraft:" + name
+}
+
+// blockQuery matches a block by name and optionally some of its states, name[key=value,...]
+type blockQuery struct {
+	name       string
+	properties map[string]string
+}
+
+func parseBlockQuery(s string) (q blockQuery, err error) {
+	name, props, hasProps := strings.Cut(s, "[")
+	q.name = withNamespace(strings.TrimSpace(name))
+	if !hasProps {
+		return q, nil
+	}
+	props, ok := strings.CutSuffix(props, "]")
+	if !ok {
+		return q, fmt.Errorf("invalid block query %q, missing ]", s)
+	}
+	q.properties = make(map[string]string)
+	for _, prop := range strings.Split(props, ",") {
+		key, value, ok := strings.Cut(prop, "=")
+		if !ok {
+			return q, fmt.Errorf("invalid block query %q, expected key=value", s)
+		}
+		q.properties[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return q, nil
+}
+
+func matchValue(v any, want string) bool {
+	switch v := v.(type) {
+	case bool:
+		return (v && (want == "true" || want == "1")) || (!v && (want == "false" || want == "0"))
+	case uint8:
+		if want == "true" {
+			return v == 1
+		}
+		if want == "false" {
+			return v == 0
+		}
+	}
+	return fmt.Sprint(v) == want
+}
+
+func (q *blockQuery) matches(name string, properties map[string]any) bool {
+	if name != q.name {
+		return false
+	}
+	for key, want := range q.properties {
+		v, ok := properties[key]
+		if !ok || !matchValue(v, want) {
+			return false
+		}
+	}
+	return true
+}
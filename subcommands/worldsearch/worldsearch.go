@@ -0,0 +1,294 @@
+package worldsearch
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/subcommands/merge"
+	"github.com/bedrock-tool/bedrocktool/subcommands/render"
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/worldinventory"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/sirupsen/logrus"
+)
+
+type WorldSearchCMD struct {
+	WorldPath   string
+	Block       string
+	BlockEntity string
+	Item        string
+	Entity      string
+	Markers     string
+	Limit       int
+}
+
+func (*WorldSearchCMD) Name() string { return "world-search" }
+func (*WorldSearchCMD) Synopsis() string {
+	return "find blocks, block entities, items and entities in a world"
+}
+
+func (c *WorldSearchCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.WorldPath, "world", "", "world path")
+	f.StringVar(&c.Block, "block", "", "block to find, name or name[state=value,...]")
+	f.StringVar(&c.BlockEntity, "block-entity", "", "block entity id to find, for example Beacon")
+	f.StringVar(&c.Item, "item", "", "item to find in containers")
+	f.StringVar(&c.Entity, "entity", "", "entity type to find")
+	f.StringVar(&c.Markers, "markers", "", "write the results as markers for render -tiles to this json file")
+	f.IntVar(&c.Limit, "limit", 1000, "stop after this many results, 0 for no limit")
+}
+
+// Result is one thing that was found
+type Result struct {
+	Dimension string  `json:"dimension"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Z         float64 `json:"z"`
+	Kind      string  `json:"kind"`
+	Name      string  `json:"name"`
+	Detail    string  `json:"detail,omitempty"`
+}
+
+var kindColors = map[string]string{
+	"block":        "#ff4040",
+	"block_entity": "#40a0ff",
+	"item":         "#ffd700",
+	"entity":       "#40ff40",
+}
+
+type search struct {
+	blocks      *merge.BlockRegistry
+	block       *blockQuery
+	blockEntity string
+	item        string
+	entity      string
+	limit       int
+
+	// blockMatches caches if a runtime id matches the block query
+	blockMatches map[uint32]bool
+	results      []Result
+}
+
+func (c *WorldSearchCMD) Execute(ctx context.Context) error {
+	blockReg := &merge.BlockRegistry{
+		BlockRegistry: world.DefaultBlockRegistry,
+		Rids:          make(map[uint32]merge.Block),
+	}
+
+	s := &search{
+		blocks:       blockReg,
+		blockEntity:  c.BlockEntity,
+		item:         withNamespace(c.Item),
+		entity:       withNamespace(c.Entity),
+		limit:        c.Limit,
+		blockMatches: make(map[uint32]bool),
+	}
+	if c.Block != "" {
+		q, err := parseBlockQuery(c.Block)
+		if err != nil {
+			return err
+		}
+		s.block = &q
+	}
+	if s.block == nil && s.blockEntity == "" && s.item == "" && s.entity == "" {
+		return fmt.Errorf("nothing to search for, use -block, -block-entity, -item or -entity")
+	}
+
+	var ok bool
+	c.WorldPath, ok = behaviourpack.AskWorldPath(ctx, c.WorldPath)
+	if !ok {
+		return nil
+	}
+
+	db, err := behaviourpack.OpenWorld(c.WorldPath, blockReg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	it := db.NewColumnIterator(nil)
+	for it.Next() && !s.full() {
+		if ctx.Err() != nil {
+			break
+		}
+		dim, _ := world.DimensionID(it.Dimension())
+		s.column(behaviourpack.DimensionName(dim), it.Position(), it.Column())
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range s.results {
+		fmt.Printf("%s %d %d %d %s %s %s\n", r.Dimension, int(r.X), int(r.Y), int(r.Z), r.Kind, r.Name, r.Detail)
+	}
+	if s.full() {
+		logrus.Warnf("Stopped after %d results, use -limit to find more", s.limit)
+	}
+	logrus.Infof("Found %d results", len(s.results))
+
+	if c.Markers != "" {
+		err = writeMarkers(c.Markers, s.results)
+		if err != nil {
+			return err
+		}
+		logrus.Infof("Wrote markers to %s", c.Markers)
+	}
+	return nil
+}
+
+func (s *search) full() bool {
+	return s.limit > 0 && len(s.results) >= s.limit
+}
+
+func (s *search) add(r Result) {
+	if !s.full() {
+		s.results = append(s.results, r)
+	}
+}
+
+func (s *search) matchBlock(rid uint32) bool {
+	match, ok := s.blockMatches[rid]
+	if !ok {
+		name, properties, _ := s.blocks.RuntimeIDToState(rid)
+		match = s.block.matches(name, properties)
+		s.blockMatches[rid] = match
+	}
+	return match
+}
+
+func (s *search) column(dim string, pos world.ChunkPos, col *chunk.Column) {
+	if s.block != nil {
+		s.searchBlocks(dim, pos, col.Chunk)
+	}
+
+	for _, be := range col.BlockEntities {
+		id, _ := be.Data["id"].(string)
+		at := Result{Dimension: dim, X: float64(be.Pos.X()), Y: float64(be.Pos.Y()), Z: float64(be.Pos.Z())}
+		if s.blockEntity != "" && strings.EqualFold(id, s.blockEntity) {
+			r := at
+			r.Kind, r.Name = "block_entity", id
+			if name, _ := be.Data["CustomName"].(string); name != "" {
+				r.Detail = name
+			}
+			s.add(r)
+		}
+		if s.item == "" {
+			continue
+		}
+		for _, row := range worldinventory.FromBlockEntity(be.Data) {
+			if row.Item != s.item {
+				continue
+			}
+			r := at
+			r.Kind, r.Name = "item", s.item
+			r.Detail = fmt.Sprintf("%dx in %s slot %s", row.Count, id, row.Slot)
+			s.add(r)
+		}
+	}
+
+	if s.entity != "" {
+		for _, ent := range col.Entities {
+			id, _ := ent.Data["identifier"].(string)
+			if id != s.entity {
+				continue
+			}
+			p, ok := ent.Data["Pos"].([]any)
+			if !ok || len(p) != 3 {
+				continue
+			}
+			x, _ := p[0].(float32)
+			y, _ := p[1].(float32)
+			z, _ := p[2].(float32)
+			r := Result{Dimension: dim, X: float64(x), Y: float64(y), Z: float64(z), Kind: "entity", Name: id}
+			if name, _ := ent.Data["CustomName"].(string); name != "" {
+				r.Detail = name
+			}
+			s.add(r)
+		}
+	}
+}
+
+func (s *search) searchBlocks(dim string, pos world.ChunkPos, c *chunk.Chunk) {
+	for i, sub := range c.Sub() {
+		if sub.Empty() {
+			continue
+		}
+		baseY := int(c.SubY(int16(i)))
+		for layerIndex, layer := range sub.Layers() {
+			// skip the layer without looking at every block when nothing in its palette matches
+			p := layer.Palette()
+			var found bool
+			for j := 0; j < p.Len() && !found; j++ {
+				found = s.matchBlock(p.Value(uint16(j)))
+			}
+			if !found {
+				continue
+			}
+			for y := byte(0); y < 16; y++ {
+				for x := byte(0); x < 16; x++ {
+					for z := byte(0); z < 16; z++ {
+						rid := layer.At(x, y, z)
+						if !s.matchBlock(rid) {
+							continue
+						}
+						name, _, _ := s.blocks.RuntimeIDToState(rid)
+						r := Result{
+							Dimension: dim,
+							X:         float64(int(pos[0])*16 + int(x)),
+							Y:         float64(baseY + int(y)),
+							Z:         float64(int(pos[1])*16 + int(z)),
+							Kind:      "block",
+							Name:      name,
+						}
+						if layerIndex > 0 {
+							r.Detail = fmt.Sprintf("layer %d", layerIndex)
+						}
+						s.add(r)
+						if s.full() {
+							return
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// writeMarkers writes the results in the marker format of the tile viewer
+func writeMarkers(filename string, results []Result) error {
+	markers := make([]render.Marker, 0, len(results))
+	for _, r := range results {
+		label := r.Name
+		if r.Detail != "" {
+			label += " " + r.Detail
+		}
+		// results only come from dimensions that ParseDimension knows
+		dim, _ := behaviourpack.ParseDimension(r.Dimension)
+		markers = append(markers, render.Marker{
+			Dimension: dim,
+			X:         r.X,
+			Y:         r.Y,
+			Z:         r.Z,
+			Label:     label,
+			Color:     kindColors[r.Kind],
+		})
+	}
+	data, err := json.MarshalIndent(markers, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+func init() {
+	commands.RegisterCommand(&WorldSearchCMD{})
+}
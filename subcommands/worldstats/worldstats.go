@@ -0,0 +1,217 @@
+package worldstats
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/subcommands/merge"
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+)
+
+type WorldStatsCMD struct {
+	WorldPath string
+	JSON      bool
+}
+
+func (*WorldStatsCMD) Name() string     { return "world-stats" }
+func (*WorldStatsCMD) Synopsis() string { return "show what blocks, entities and chunks a world has" }
+
+func (c *WorldStatsCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.WorldPath, "world", "", "world path")
+	f.BoolVar(&c.JSON, "json", false, "print the stats as json")
+}
+
+// DimensionStats are the chunk counts of one dimension, bounds are in chunks
+type DimensionStats struct {
+	Chunks        int      `json:"chunks"`
+	EmptyChunks   int      `json:"empty_chunks"`
+	AirOnlyChunks int      `json:"air_only_chunks"`
+	Min           [2]int32 `json:"min"`
+	Max           [2]int32 `json:"max"`
+}
+
+type Stats struct {
+	Dimensions    map[string]*DimensionStats `json:"dimensions"`
+	Blocks        map[string]int             `json:"blocks"`
+	CustomBlocks  map[string]int             `json:"custom_blocks"`
+	Entities      map[string]int             `json:"entities"`
+	BlockEntities map[string]int             `json:"block_entities"`
+}
+
+func (c *WorldStatsCMD) Execute(ctx context.Context) error {
+	blockReg := &merge.BlockRegistry{
+		BlockRegistry: world.DefaultBlockRegistry,
+		Rids:          make(map[uint32]merge.Block),
+	}
+
+	var ok bool
+	c.WorldPath, ok = behaviourpack.AskWorldPath(ctx, c.WorldPath)
+	if !ok {
+		return nil
+	}
+
+	db, err := behaviourpack.OpenWorld(c.WorldPath, blockReg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, behaviorPacks, err := behaviourpack.ReadWorldPacks(c.WorldPath)
+	if err != nil {
+		return err
+	}
+	entries, err := behaviourpack.BlockEntries(behaviorPacks)
+	if err != nil {
+		return err
+	}
+	customBlocks := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		customBlocks[entry.Name] = true
+	}
+
+	stats, err := collect(ctx, db, blockReg, customBlocks)
+	if err != nil {
+		return err
+	}
+
+	if c.JSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "\t")
+		return e.Encode(stats)
+	}
+	stats.print()
+	return nil
+}
+
+// countPalette adds the count of every block in a layer to counts by runtime id
+func countPalette(layer *chunk.PalettedStorage, counts map[uint32]int) {
+	if p := layer.Palette(); p.Len() == 1 {
+		counts[p.Value(0)] += 16 * 16 * 16
+		return
+	}
+	for x := byte(0); x < 16; x++ {
+		for y := byte(0); y < 16; y++ {
+			for z := byte(0); z < 16; z++ {
+				counts[layer.At(x, y, z)]++
+			}
+		}
+	}
+}
+
+func collect(ctx context.Context, db *mcdb.DB, blockReg *merge.BlockRegistry, customBlocks map[string]bool) (*Stats, error) {
+	stats := &Stats{
+		Dimensions:    make(map[string]*DimensionStats),
+		Blocks:        make(map[string]int),
+		CustomBlocks:  make(map[string]int),
+		Entities:      make(map[string]int),
+		BlockEntities: make(map[string]int),
+	}
+	airRID, _ := blockReg.StateToRuntimeID("minecraft:air", map[string]any{})
+	rids := make(map[uint32]int)
+
+	it := db.NewColumnIterator(nil)
+	defer it.Release()
+	for it.Next() {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		dim, _ := world.DimensionID(it.Dimension())
+		name := behaviourpack.DimensionName(dim)
+		ds, ok := stats.Dimensions[name]
+		if !ok {
+			ds = &DimensionStats{
+				Min: [2]int32{math.MaxInt32, math.MaxInt32},
+				Max: [2]int32{math.MinInt32, math.MinInt32},
+			}
+			stats.Dimensions[name] = ds
+		}
+		pos := it.Position()
+		ds.Chunks++
+		ds.Min = [2]int32{min(ds.Min[0], pos[0]), min(ds.Min[1], pos[1])}
+		ds.Max = [2]int32{max(ds.Max[0], pos[0]), max(ds.Max[1], pos[1])}
+
+		column := it.Column()
+		empty, airOnly := true, true
+		chunkRids := make(map[uint32]int)
+		for _, sub := range column.Chunk.Sub() {
+			if sub.Empty() {
+				continue
+			}
+			empty = false
+			countPalette(sub.Layer(0), chunkRids)
+		}
+		for rid, n := range chunkRids {
+			if rid != airRID {
+				airOnly = false
+			}
+			rids[rid] += n
+		}
+		if empty {
+			ds.EmptyChunks++
+		} else if airOnly {
+			ds.AirOnlyChunks++
+		}
+
+		for _, ent := range column.Entities {
+			id, _ := ent.Data["identifier"].(string)
+			stats.Entities[id]++
+		}
+		for _, be := range column.BlockEntities {
+			id, _ := be.Data["id"].(string)
+			stats.BlockEntities[id]++
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	delete(rids, airRID)
+	for rid, n := range rids {
+		name, _, _ := blockReg.RuntimeIDToState(rid)
+		stats.Blocks[name] += n
+		if customBlocks[name] {
+			stats.CustomBlocks[name] += n
+		}
+	}
+	return stats, nil
+}
+
+// printCounts prints counts sorted from most to least
+func printCounts(title string, counts map[string]int) {
+	fmt.Printf("\n%s (%d types):\n", title, len(counts))
+	names := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), strings.Compare(a, b))
+	})
+	for _, name := range names {
+		fmt.Printf("  %10d %s\n", counts[name], name)
+	}
+}
+
+func (s *Stats) print() {
+	fmt.Println("Dimensions:")
+	for _, name := range slices.Sorted(maps.Keys(s.Dimensions)) {
+		ds := s.Dimensions[name]
+		fmt.Printf("  %s: %d chunks, %d empty, %d only air, chunks %d,%d to %d,%d\n",
+			name, ds.Chunks, ds.EmptyChunks, ds.AirOnlyChunks, ds.Min[0], ds.Min[1], ds.Max[0], ds.Max[1])
+	}
+	printCounts("Blocks", s.Blocks)
+	printCounts("Custom blocks", s.CustomBlocks)
+	printCounts("Entities", s.Entities)
+	printCounts("Block entities", s.BlockEntities)
+}
+
+func init() {
+	commands.RegisterCommand(&WorldStatsCMD{})
+}
@@ -0,0 +1,68 @@
+package worldtext
+
+import (
+	"context"
+	"flag"
+	"path"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/subcommands/merge"
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/worldtext"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sirupsen/logrus"
+)
+
+type WorldTextCMD struct {
+	WorldPath string
+	Out       string
+}
+
+func (*WorldTextCMD) Name() string { return "world-text" }
+func (*WorldTextCMD) Synopsis() string {
+	return "export the text of signs, lecterns and books in a world"
+}
+
+func (c *WorldTextCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.WorldPath, "world", "", "world path")
+	f.StringVar(&c.Out, "out", "world-text.json", "output path, .json or .csv")
+}
+
+func (c *WorldTextCMD) Execute(ctx context.Context) error {
+	blockReg := &merge.BlockRegistry{
+		BlockRegistry: world.DefaultBlockRegistry,
+		Rids:          make(map[uint32]merge.Block),
+	}
+
+	var ok bool
+	c.WorldPath, ok = behaviourpack.AskWorldPath(ctx, c.WorldPath)
+	if !ok {
+		return nil
+	}
+
+	c.Out = path.Clean(strings.ReplaceAll(c.Out, "\\", "/"))
+
+	db, err := behaviourpack.OpenWorld(c.WorldPath, blockReg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	entries, err := worldtext.FromDB(db, blockReg)
+	if err != nil {
+		return err
+	}
+
+	err = worldtext.WriteFile(c.Out, entries)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Wrote %d texts to %s", len(entries), c.Out)
+	return nil
+}
+
+func init() {
+	commands.RegisterCommand(&WorldTextCMD{})
+}
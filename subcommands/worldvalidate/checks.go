@@ -0,0 +1,307 @@
+package worldvalidate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/dbkeys"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+// problem is something wrong in the world, fix is nil when it cant be fixed automatically
+type problem struct {
+	kind     string
+	location string
+	detail   string
+	fix      func() error
+}
+
+type columnID struct {
+	dim int
+	pos world.ChunkPos
+}
+
+func (id columnID) String() string {
+	return fmt.Sprintf("chunk %d,%d dim %d", id.pos[0], id.pos[1], id.dim)
+}
+
+// column is what the key scan found about a chunk
+type column struct {
+	keys        [][]byte
+	subVersions map[byte]int
+}
+
+type validator struct {
+	ldb      *leveldb.DB
+	problems []problem
+
+	columns map[columnID]*column
+	// entityIDs maps the unique id in the nbt of entities to their key
+	entityIDs map[int64]string
+	actorKeys map[int64]bool
+	digp      map[string][]int64
+}
+
+func (v *validator) report(kind, location, detail string, fix func() error) {
+	v.problems = append(v.problems, problem{kind: kind, location: location, detail: detail, fix: fix})
+}
+
+// decodeCompounds decodes nbt compounds that are appended to each other,
+// returning the ones that could be read and the error that stopped it
+func decodeCompounds(value []byte) ([]map[string]any, error) {
+	var out []map[string]any
+	buf := bytes.NewBuffer(value)
+	dec := nbt.NewDecoderWithEncoding(buf, nbt.LittleEndian)
+	for buf.Len() > 0 {
+		var m map[string]any
+		if err := dec.Decode(&m); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return out, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func encodeCompounds(compounds []map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := nbt.NewEncoderWithEncoding(&buf, nbt.LittleEndian)
+	for _, m := range compounds {
+		if err := enc.Encode(m); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (v *validator) put(key, value []byte) func() error {
+	return func() error { return v.ldb.Put(key, value, nil) }
+}
+
+func (v *validator) delete(key []byte) func() error {
+	return func() error { return v.ldb.Delete(key, nil) }
+}
+
+// scanKey checks a single key of the database
+func (v *validator) scanKey(key, value []byte) {
+	if k, ok := dbkeys.ParseChunkKey(key); ok {
+		id := columnID{k.Dimension, k.Pos}
+		col, ok := v.columns[id]
+		if !ok {
+			col = &column{subVersions: make(map[byte]int)}
+			v.columns[id] = col
+		}
+		col.keys = append(col.keys, key)
+
+		switch k.Tag {
+		case dbkeys.TagSubChunk:
+			v.checkSubChunk(id, col, k, key, value)
+		case dbkeys.TagBlockEntity:
+			v.checkBlockEntities(id, key, value)
+		case dbkeys.TagEntity:
+			v.checkLegacyEntities(id, key, value)
+		}
+		return
+	}
+
+	if _, _, ok := dbkeys.ParseEntityIDsKey(key); ok {
+		v.digp[string(key)] = dbkeys.EntityIDs(value)
+		return
+	}
+
+	name := string(key)
+	switch {
+	case strings.HasPrefix(name, dbkeys.EntityPrefix) && len(key) == len(dbkeys.EntityPrefix)+8:
+		v.checkEntity(key, value)
+	case strings.HasPrefix(name, "map_"):
+		var m map[string]any
+		if err := nbt.UnmarshalEncoding(value, &m, nbt.LittleEndian); err != nil {
+			v.report("map", name, err.Error(), v.delete(key))
+		}
+	}
+}
+
+func (v *validator) checkSubChunk(id columnID, col *column, k dbkeys.ChunkKey, key, value []byte) {
+	location := fmt.Sprintf("%s sub chunk %d", id, k.SubY)
+	if len(value) < 2 {
+		v.report("sub chunk", location, "too short", v.delete(key))
+		return
+	}
+	version := value[0]
+	col.subVersions[version]++
+	switch version {
+	case 1, 8:
+	case 9:
+		if len(value) < 3 {
+			v.report("sub chunk", location, "too short", v.delete(key))
+			return
+		}
+		if int8(value[2]) != k.SubY {
+			fixed := slices.Clone(value)
+			fixed[2] = byte(k.SubY)
+			v.report("sub chunk", location, fmt.Sprintf("stored as sub chunk %d", int8(value[2])), v.put(key, fixed))
+		}
+	default:
+		v.report("sub chunk", location, fmt.Sprintf("unknown version %d", version), v.delete(key))
+	}
+}
+
+func (v *validator) checkBlockEntities(id columnID, key, value []byte) {
+	compounds, err := decodeCompounds(value)
+	kept := make([]map[string]any, 0, len(compounds))
+	var bad []string
+	for _, m := range compounds {
+		x, okX := m["x"].(int32)
+		y, okY := m["y"].(int32)
+		z, okZ := m["z"].(int32)
+		if !okX || !okY || !okZ {
+			bad = append(bad, fmt.Sprintf("%v without a position", m["id"]))
+			continue
+		}
+		if x>>4 != id.pos[0] || z>>4 != id.pos[1] {
+			bad = append(bad, fmt.Sprintf("%v at %d,%d,%d is outside of the chunk", m["id"], x, y, z))
+			continue
+		}
+		kept = append(kept, m)
+	}
+	if err == nil && len(bad) == 0 {
+		return
+	}
+	if err != nil {
+		bad = append(bad, fmt.Sprintf("nbt after %d block entities: %s", len(compounds), err))
+	}
+	v.report("block entity", id.String(), strings.Join(bad, ", "), func() error {
+		if len(kept) == 0 {
+			return v.ldb.Delete(key, nil)
+		}
+		data, err := encodeCompounds(kept)
+		if err != nil {
+			return err
+		}
+		return v.ldb.Put(key, data, nil)
+	})
+}
+
+// badPosition checks the Pos of an entity for NaN and infinite values
+func badPosition(m map[string]any) bool {
+	pos, ok := m["Pos"].([]any)
+	if !ok || len(pos) != 3 {
+		return true
+	}
+	for _, p := range pos {
+		f, ok := p.(float32)
+		if !ok || math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) checkEntity(key, value []byte) {
+	id := int64(binary.LittleEndian.Uint64(key[len(dbkeys.EntityPrefix):]))
+	location := fmt.Sprintf("entity %d", id)
+
+	var m map[string]any
+	if err := nbt.UnmarshalEncoding(value, &m, nbt.LittleEndian); err != nil {
+		v.report("entity", location, err.Error(), v.delete(key))
+		return
+	}
+	if badPosition(m) {
+		v.report("entity", location, fmt.Sprintf("%v has an invalid position %v", m["identifier"], m["Pos"]), v.delete(key))
+		return
+	}
+	if !v.checkUniqueID(location, m, v.delete(key)) {
+		return
+	}
+	// entities that are deleted by a fix are left out, so lists referencing them are fixed too
+	v.actorKeys[id] = true
+}
+
+func (v *validator) checkLegacyEntities(id columnID, key, value []byte) {
+	compounds, err := decodeCompounds(value)
+	kept := make([]map[string]any, 0, len(compounds))
+	var bad []string
+	for _, m := range compounds {
+		if badPosition(m) {
+			bad = append(bad, fmt.Sprintf("%v has an invalid position %v", m["identifier"], m["Pos"]))
+			continue
+		}
+		if uid, ok := m["UniqueID"].(int64); ok {
+			if other, dup := v.entityIDs[uid]; dup {
+				bad = append(bad, fmt.Sprintf("%v has the unique id %d of %s", m["identifier"], uid, other))
+				continue
+			}
+			v.entityIDs[uid] = id.String()
+		}
+		kept = append(kept, m)
+	}
+	if err == nil && len(bad) == 0 {
+		return
+	}
+	if err != nil {
+		bad = append(bad, fmt.Sprintf("nbt after %d entities: %s", len(compounds), err))
+	}
+	v.report("entity", id.String(), strings.Join(bad, ", "), func() error {
+		if len(kept) == 0 {
+			return v.ldb.Delete(key, nil)
+		}
+		data, err := encodeCompounds(kept)
+		if err != nil {
+			return err
+		}
+		return v.ldb.Put(key, data, nil)
+	})
+}
+
+// checkUniqueID reports entities with a unique id that was already used, false if it is a duplicate
+func (v *validator) checkUniqueID(location string, m map[string]any, fix func() error) bool {
+	uid, ok := m["UniqueID"].(int64)
+	if !ok {
+		return true
+	}
+	if other, dup := v.entityIDs[uid]; dup {
+		v.report("entity", location, fmt.Sprintf("%v has the unique id %d of %s", m["identifier"], uid, other), fix)
+		return false
+	}
+	v.entityIDs[uid] = location
+	return true
+}
+
+// checkEntityLists runs after all keys were read, since entities can be stored after the list that references them
+func (v *validator) checkEntityLists() {
+	for key, ids := range v.digp {
+		kept := make([]int64, 0, len(ids))
+		var missing []string
+		for _, id := range ids {
+			if v.actorKeys[id] {
+				kept = append(kept, id)
+			} else {
+				missing = append(missing, fmt.Sprint(id))
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		pos, dim, _ := dbkeys.ParseEntityIDsKey([]byte(key))
+		v.report("entity", columnID{dim, pos}.String(), "references missing entities "+strings.Join(missing, ", "), func() error {
+			if len(kept) == 0 {
+				return v.ldb.Delete([]byte(key), nil)
+			}
+			var value []byte
+			for _, id := range kept {
+				value = binary.LittleEndian.AppendUint64(value, uint64(id))
+			}
+			return v.ldb.Put([]byte(key), value, nil)
+		})
+	}
+}
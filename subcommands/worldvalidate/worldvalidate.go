@@ -0,0 +1,250 @@
+package worldvalidate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/subcommands/merge"
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"github.com/sirupsen/logrus"
+)
+
+type WorldValidateCMD struct {
+	WorldPath string
+	Fix       bool
+}
+
+func (*WorldValidateCMD) Name() string { return "world-validate" }
+func (*WorldValidateCMD) Synopsis() string {
+	return "check a world for data that can crash the game"
+}
+
+func (c *WorldValidateCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.WorldPath, "world", "", "world path")
+	f.BoolVar(&c.Fix, "fix", false, "repair or remove the broken data")
+}
+
+func (c *WorldValidateCMD) Execute(ctx context.Context) error {
+	blockReg := &merge.BlockRegistry{
+		BlockRegistry: world.DefaultBlockRegistry,
+		Rids:          make(map[uint32]merge.Block),
+	}
+
+	var ok bool
+	c.WorldPath, ok = behaviourpack.AskWorldPath(ctx, c.WorldPath)
+	if !ok {
+		return nil
+	}
+
+	db, err := behaviourpack.OpenWorld(c.WorldPath, blockReg, !c.Fix)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	v := &validator{
+		ldb:       db.LDB(),
+		columns:   make(map[columnID]*column),
+		entityIDs: make(map[int64]string),
+		actorKeys: make(map[int64]bool),
+		digp:      make(map[string][]int64),
+	}
+
+	it := db.LDB().NewIterator(nil, nil)
+	for it.Next() {
+		if ctx.Err() != nil {
+			break
+		}
+		v.scanKey(slices.Clone(it.Key()), slices.Clone(it.Value()))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	v.checkEntityLists()
+
+	for id, col := range v.columns {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		v.checkColumn(db, id, col)
+	}
+
+	resourcePacks, behaviorPacks, err := behaviourpack.ReadWorldPacks(c.WorldPath)
+	if err != nil {
+		v.report("pack", c.WorldPath, err.Error(), nil)
+	} else {
+		v.checkCustomBlocks(blockReg, behaviorPacks)
+		v.checkPackReferences(c.WorldPath, resourcePacks, behaviorPacks)
+	}
+
+	if len(v.problems) == 0 {
+		logrus.Infof("No problems found in %d chunks", len(v.columns))
+		return nil
+	}
+	for _, p := range v.problems {
+		fmt.Printf("[%s] %s: %s\n", p.kind, p.location, p.detail)
+	}
+	logrus.Warnf("Found %d problems", len(v.problems))
+	if !c.Fix {
+		return nil
+	}
+
+	var fixed, unfixable int
+	for _, p := range v.problems {
+		if p.fix == nil {
+			unfixable++
+			continue
+		}
+		if err := p.fix(); err != nil {
+			logrus.Errorf("fixing %s %s: %s", p.kind, p.location, err)
+			continue
+		}
+		fixed++
+	}
+	logrus.Infof("Fixed %d problems, %d have to be fixed manually", fixed, unfixable)
+	return nil
+}
+
+// checkColumn checks that a column decodes with the block registry, it runs after the key scan
+// so the fix can store the column again after its keys were fixed
+func (v *validator) checkColumn(db *mcdb.DB, id columnID, col *column) {
+	deleteColumn := func() error {
+		for _, key := range col.keys {
+			if err := v.ldb.Delete(key, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dim, ok := world.DimensionByID(id.dim)
+	if !ok {
+		v.report("chunk", id.String(), "unknown dimension", deleteColumn)
+		return
+	}
+	restore := func() error {
+		loaded, err := db.LoadColumn(id.pos, dim)
+		if err != nil {
+			return deleteColumn()
+		}
+		return db.StoreColumn(id.pos, dim, loaded)
+	}
+
+	_, err := db.LoadColumn(id.pos, dim)
+	if err != nil {
+		v.report("chunk", id.String(), err.Error(), restore)
+		return
+	}
+	if len(col.subVersions) > 1 {
+		var versions []string
+		for version, n := range col.subVersions {
+			versions = append(versions, fmt.Sprintf("%d of version %d", n, version))
+		}
+		slices.Sort(versions)
+		v.report("chunk", id.String(), "sub chunks have different versions, "+strings.Join(versions, ", "), restore)
+	}
+}
+
+// checkCustomBlocks reports blocks in the chunks that are neither vanilla nor defined in a behaviour pack,
+// the block registry only knows about them after the chunks were loaded
+func (v *validator) checkCustomBlocks(blockReg *merge.BlockRegistry, behaviorPacks []resource.Pack) {
+	entries, err := behaviourpack.BlockEntries(behaviorPacks)
+	if err != nil {
+		v.report("pack", "behavior_packs", err.Error(), nil)
+		return
+	}
+	defined := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		defined[entry.Name] = true
+	}
+	missing := make(map[string]bool)
+	for rid := range blockReg.Rids {
+		name, _, _ := blockReg.RuntimeIDToState(rid)
+		if !defined[name] {
+			missing[name] = true
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(missing)) {
+		v.report("block", name, "is not defined in a behaviour pack of the world", nil)
+	}
+}
+
+type packReference struct {
+	UUID    string `json:"pack_id"`
+	Version [3]int `json:"version"`
+}
+
+// checkPackReferences checks that the packs in world_behavior_packs.json and world_resource_packs.json
+// are in the world folder
+func (v *validator) checkPackReferences(worldPath string, resourcePacks, behaviorPacks []resource.Pack) {
+	for _, refs := range []struct {
+		file  string
+		packs []resource.Pack
+	}{
+		{"world_behavior_packs.json", behaviorPacks},
+		{"world_resource_packs.json", resourcePacks},
+	} {
+		filename := path.Join(worldPath, refs.file)
+		data, err := os.ReadFile(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			v.report("pack", refs.file, err.Error(), nil)
+			continue
+		}
+		var references []packReference
+		if err := json.Unmarshal(data, &references); err != nil {
+			v.report("pack", refs.file, err.Error(), nil)
+			continue
+		}
+
+		available := make(map[string]bool)
+		for _, pack := range refs.packs {
+			header := pack.Manifest().Header
+			available[header.UUID.String()] = true
+		}
+		var kept []packReference
+		var missing []string
+		for _, ref := range references {
+			if available[ref.UUID] {
+				kept = append(kept, ref)
+			} else {
+				missing = append(missing, ref.UUID)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		v.report("pack", refs.file, "references missing packs "+strings.Join(missing, ", "), func() error {
+			if kept == nil {
+				kept = []packReference{}
+			}
+			data, err := json.Marshal(kept)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(filename, data, 0o644)
+		})
+	}
+}
+
+func init() {
+	commands.RegisterCommand(&WorldValidateCMD{})
+}
@@ -13,6 +13,7 @@ import (
 	"gioui.org/x/component"
 	"github.com/bedrock-tool/bedrocktool/ui/gui/pages"
 	"github.com/bedrock-tool/bedrocktool/ui/messages"
+	"github.com/bedrock-tool/bedrocktool/utils"
 )
 
 type (
@@ -36,10 +37,12 @@ type Page struct {
 	voidGen    bool
 	worldName  string
 	back       widget.Clickable
+	renderMode widget.Enum
 }
 
 func New(invalidate func()) pages.Page {
 	return &Page{
+		renderMode: widget.Enum{Value: utils.RenderModeTopDown.String()},
 		worldMap: &Map2{
 			images:   make(map[image.Point]*image.RGBA),
 			imageOps: make(map[image.Point]paint.ImageOp),
@@ -120,6 +123,30 @@ func displayWorldProcessing(gtx C, th *material.Theme, entry *processingWorld) D
 	})
 }
 
+// layoutRenderModes draws the buttons to switch how the map is drawn
+func (p *Page) layoutRenderModes(gtx C, th *material.Theme) D {
+	if p.renderMode.Update(gtx) {
+		messages.Router.Handle(&messages.Message{
+			Source: "ui",
+			Target: "mapui",
+			Data: messages.SetValue{
+				Name:  "renderMode",
+				Value: p.renderMode.Value,
+			},
+		})
+	}
+
+	return layout.UniformInset(5).Layout(gtx, func(gtx C) D {
+		return component.Surface(th).Layout(gtx, func(gtx C) D {
+			var children []layout.FlexChild
+			for _, name := range utils.RenderModeNames() {
+				children = append(children, layout.Rigid(material.RadioButton(th, &p.renderMode, name, name).Layout))
+			}
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, children...)
+		})
+	})
+}
+
 func (p *Page) Layout(gtx C, th *material.Theme) D {
 	if p.back.Clicked(gtx) {
 		messages.Router.Handle(&messages.Message{
@@ -133,7 +160,12 @@ func (p *Page) Layout(gtx C, th *material.Theme) D {
 		layout.Stacked(func(gtx C) D {
 			switch p.State {
 			case messages.UIStateMain:
-				return p.worldMap.Layout(gtx)
+				return layout.Stack{}.Layout(gtx,
+					layout.Stacked(p.worldMap.Layout),
+					layout.Stacked(func(gtx C) D {
+						return p.layoutRenderModes(gtx, th)
+					}),
+				)
 			case messages.UIStateFinished:
 				return layout.UniformInset(25).Layout(gtx, func(gtx C) D {
 					return layout.Flex{
@@ -209,6 +241,8 @@ func (u *Page) HandleMessage(msg *messages.Message) *messages.Message {
 			}
 		case "worldName":
 			u.worldName = m.Value
+		case "renderMode":
+			u.renderMode.Value = m.Value
 		}
 	case messages.FinishedSavingWorld:
 		u.l.Lock()
@@ -1,21 +1,40 @@
 package messages
 
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
 type router struct {
 	handlers map[string]HandlerFunc
+	l        sync.RWMutex
 }
 
 func (r *router) AddHandler(name string, handler HandlerFunc) {
+	r.l.Lock()
+	defer r.l.Unlock()
 	r.handlers[name] = handler
 }
 
+func (r *router) RemoveHandler(name string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	delete(r.handlers, name)
+}
+
 func (r *router) Handle(msg *Message) *Message {
+	r.l.RLock()
 	if msg.Target == "" {
-		for _, handler := range r.handlers {
+		handlers := slices.Collect(maps.Values(r.handlers))
+		r.l.RUnlock()
+		for _, handler := range handlers {
 			handler(msg)
 		}
 		return nil
 	}
 	handler, ok := r.handlers[msg.Target]
+	r.l.RUnlock()
 	if !ok {
 		return nil
 	}
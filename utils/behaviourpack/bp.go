@@ -18,6 +18,8 @@ type Pack struct {
 	items         map[string]*itemBehaviour
 	entities      map[string]*entityBehaviour
 	biomes        []biomeBehaviour
+	recipes       map[string]*recipeBehaviour
+	itemNames     map[int32]string
 }
 
 func New(name string) *Pack {
@@ -43,9 +45,11 @@ func New(name string) *Pack {
 			Dependencies: []resource.Dependency{},
 			Capabilities: []resource.Capability{},
 		},
-		blocks:   make(map[string]*BlockBehaviour),
-		items:    make(map[string]*itemBehaviour),
-		entities: make(map[string]*entityBehaviour),
+		blocks:    make(map[string]*BlockBehaviour),
+		items:     make(map[string]*itemBehaviour),
+		entities:  make(map[string]*entityBehaviour),
+		recipes:   make(map[string]*recipeBehaviour),
+		itemNames: make(map[int32]string),
 	}
 }
 
@@ -91,8 +95,12 @@ func (bp *Pack) HasEntities() bool {
 	return len(bp.entities) > 0
 }
 
+func (bp *Pack) HasRecipes() bool {
+	return len(bp.recipes) > 0
+}
+
 func (bp *Pack) HasContent() bool {
-	return bp.HasBlocks() || bp.HasItems()
+	return bp.HasBlocks() || bp.HasItems() || bp.HasRecipes()
 }
 
 func splitNamespace(identifier string) (ns, name string) {
@@ -152,6 +160,15 @@ func (bp *Pack) Save(fs utils.WriterFS, fpath string) error {
 			}
 		}
 	}
+	if bp.HasRecipes() { // recipes
+		recipesDir := path.Join(fpath, "recipes")
+		for identifier, rb := range bp.recipes {
+			err := _add_thing(recipesDir, identifier, rb)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
 	return nil
 }
@@ -1,6 +1,9 @@
 package behaviourpack
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/bedrock-tool/bedrocktool/handlers/worlds/entity"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
@@ -146,3 +149,21 @@ func (bp *Pack) AddEntity(EntityType string, attr []protocol.AttributeValue, met
 
 	bp.entities[EntityType] = entry
 }
+
+// EntityTypes returns the identifiers of all custom entities in the pack
+func (bp *Pack) EntityTypes() []string {
+	return slices.Sorted(maps.Keys(bp.entities))
+}
+
+// EntitySize returns the collision box size of an entity, zero if unknown
+func (bp *Pack) EntitySize(entityType string) (width, height float32) {
+	entry, ok := bp.entities[entityType]
+	if !ok {
+		return 0, 0
+	}
+	if box, ok := entry.MinecraftEntity.Components["minecraft:collision_box"].(map[string]any); ok {
+		width, _ = box["width"].(float32)
+		height, _ = box["height"].(float32)
+	}
+	return width, height
+}
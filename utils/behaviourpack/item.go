@@ -24,6 +24,8 @@ type itemBehaviour struct {
 }
 
 func (bp *Pack) AddItem(item protocol.ItemEntry) {
+	bp.itemNames[int32(item.RuntimeID)] = item.Name
+
 	ns, _ := splitNamespace(item.Name)
 	if ns == "minecraft" {
 		return
@@ -0,0 +1,292 @@
+package behaviourpack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const recipeFormatVersion = "1.20.10"
+
+type recipeDescription struct {
+	Identifier string `json:"identifier"`
+}
+
+type recipeItem struct {
+	Item  string `json:"item,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	Data  int16  `json:"data,omitempty"`
+	Count int32  `json:"count,omitempty"`
+}
+
+type recipeShaped struct {
+	Description recipeDescription     `json:"description"`
+	Tags        []string              `json:"tags"`
+	Pattern     []string              `json:"pattern"`
+	Key         map[string]recipeItem `json:"key"`
+	Result      any                   `json:"result"`
+	Priority    int32                 `json:"priority,omitempty"`
+}
+
+type recipeShapeless struct {
+	Description recipeDescription `json:"description"`
+	Tags        []string          `json:"tags"`
+	Ingredients []recipeItem      `json:"ingredients"`
+	Result      any               `json:"result"`
+	Priority    int32             `json:"priority,omitempty"`
+}
+
+type recipeFurnace struct {
+	Description recipeDescription `json:"description"`
+	Tags        []string          `json:"tags"`
+	Input       recipeItem        `json:"input"`
+	Output      recipeItem        `json:"output"`
+}
+
+type recipeSmithingTransform struct {
+	Description recipeDescription `json:"description"`
+	Tags        []string          `json:"tags"`
+	Template    recipeItem        `json:"template"`
+	Base        recipeItem        `json:"base"`
+	Addition    recipeItem        `json:"addition"`
+	Result      recipeItem        `json:"result"`
+}
+
+type recipeSmithingTrim struct {
+	Description recipeDescription `json:"description"`
+	Tags        []string          `json:"tags"`
+	Template    recipeItem        `json:"template"`
+	Base        recipeItem        `json:"base"`
+	Addition    recipeItem        `json:"addition"`
+}
+
+type recipeBehaviour struct {
+	FormatVersion     string                   `json:"format_version"`
+	Shaped            *recipeShaped            `json:"minecraft:recipe_shaped,omitempty"`
+	Shapeless         *recipeShapeless         `json:"minecraft:recipe_shapeless,omitempty"`
+	Furnace           *recipeFurnace           `json:"minecraft:recipe_furnace,omitempty"`
+	SmithingTransform *recipeSmithingTransform `json:"minecraft:recipe_smithing_transform,omitempty"`
+	SmithingTrim      *recipeSmithingTrim      `json:"minecraft:recipe_smithing_trim,omitempty"`
+}
+
+// recipeConverter turns network recipes into behaviour pack recipes,
+// it keeps track of the first non vanilla item used so vanilla recipes can be skipped.
+type recipeConverter struct {
+	itemNames map[int32]string
+	// namespace of the first non vanilla item
+	namespace string
+	err       error
+}
+
+func (c *recipeConverter) use(name string) {
+	if ns, _ := splitNamespace(name); ns != "minecraft" && c.namespace == "" {
+		c.namespace = ns
+	}
+}
+
+func (c *recipeConverter) name(networkID int32) string {
+	name, ok := c.itemNames[networkID]
+	if !ok {
+		if c.err == nil {
+			c.err = fmt.Errorf("unknown item network id %d", networkID)
+		}
+		return ""
+	}
+	c.use(name)
+	return name
+}
+
+func (c *recipeConverter) descriptor(d protocol.ItemDescriptorCount) recipeItem {
+	item := recipeItem{Count: d.Count}
+	if item.Count == 1 {
+		item.Count = 0
+	}
+	switch d := d.Descriptor.(type) {
+	case *protocol.DefaultItemDescriptor:
+		item.Item = c.name(int32(d.NetworkID))
+		if d.MetadataValue != 0x7fff {
+			item.Data = d.MetadataValue
+		}
+	case *protocol.DeferredItemDescriptor:
+		item.Item = d.Name
+		if d.MetadataValue != 0x7fff {
+			item.Data = d.MetadataValue
+		}
+		c.use(d.Name)
+	case *protocol.ComplexAliasItemDescriptor:
+		item.Item = d.Name
+		c.use(d.Name)
+	case *protocol.ItemTagItemDescriptor:
+		item.Tag = d.Tag
+	default:
+		if c.err == nil {
+			c.err = fmt.Errorf("unsupported item descriptor %T", d)
+		}
+	}
+	return item
+}
+
+func (c *recipeConverter) stack(s protocol.ItemStack) recipeItem {
+	item := recipeItem{
+		Item: c.name(s.NetworkID),
+		Data: int16(s.MetadataValue),
+	}
+	if s.Count > 1 {
+		item.Count = int32(s.Count)
+	}
+	return item
+}
+
+// result returns a single item when there is only one, the game accepts both forms
+func (c *recipeConverter) result(stacks []protocol.ItemStack) any {
+	if len(stacks) == 1 {
+		return c.stack(stacks[0])
+	}
+	items := make([]recipeItem, 0, len(stacks))
+	for _, s := range stacks {
+		items = append(items, c.stack(s))
+	}
+	return items
+}
+
+func emptyDescriptor(d protocol.ItemDescriptorCount) bool {
+	switch d := d.Descriptor.(type) {
+	case *protocol.InvalidItemDescriptor:
+		return true
+	case *protocol.DefaultItemDescriptor:
+		return d.NetworkID == 0
+	}
+	return d.Count == 0
+}
+
+// shapedPattern assigns a key character to every distinct ingredient
+func (c *recipeConverter) shapedPattern(r *protocol.ShapedRecipe) ([]string, map[string]recipeItem) {
+	const keyChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	keys := make(map[string]recipeItem)
+	seen := make(map[recipeItem]string)
+	var pattern []string
+	for y := range int(r.Height) {
+		var row strings.Builder
+		for x := range int(r.Width) {
+			d := r.Input[y*int(r.Width)+x]
+			if emptyDescriptor(d) {
+				row.WriteByte(' ')
+				continue
+			}
+			item := c.descriptor(d)
+			item.Count = 0
+			k, ok := seen[item]
+			if !ok {
+				if len(seen) >= len(keyChars) {
+					c.err = fmt.Errorf("too many ingredients")
+					return nil, nil
+				}
+				k = string(keyChars[len(seen)])
+				seen[item] = k
+				keys[k] = item
+			}
+			row.WriteString(k)
+		}
+		pattern = append(pattern, row.String())
+	}
+	return pattern, keys
+}
+
+func (c *recipeConverter) furnace(r *protocol.FurnaceRecipe, withData bool) (string, *recipeFurnace) {
+	input := recipeItem{Item: c.name(r.InputType.NetworkID)}
+	if withData {
+		input.Data = int16(r.InputType.MetadataValue)
+	}
+	output := c.stack(r.Output)
+	_, inputName := splitNamespace(input.Item)
+	_, outputName := splitNamespace(output.Item)
+	return fmt.Sprintf("%s_%s_to_%s", r.Block, inputName, outputName), &recipeFurnace{
+		Tags:   []string{r.Block},
+		Input:  input,
+		Output: output,
+	}
+}
+
+// AddRecipes converts the recipes from CraftingData,
+// recipes that only use vanilla items are skipped since the game already has them
+func (bp *Pack) AddRecipes(recipes []protocol.Recipe) (skipped []error) {
+	for i, recipe := range recipes {
+		c := recipeConverter{itemNames: bp.itemNames}
+		var rb = recipeBehaviour{FormatVersion: recipeFormatVersion}
+		var id string
+		switch r := recipe.(type) {
+		case *protocol.ShapedRecipe:
+			pattern, keys := c.shapedPattern(r)
+			id = r.RecipeID
+			rb.Shaped = &recipeShaped{
+				Tags:     []string{r.Block},
+				Pattern:  pattern,
+				Key:      keys,
+				Result:   c.result(r.Output),
+				Priority: r.Priority,
+			}
+		case *protocol.ShapelessRecipe:
+			id = r.RecipeID
+			rb.Shapeless = &recipeShapeless{
+				Tags:     []string{r.Block},
+				Result:   c.result(r.Output),
+				Priority: r.Priority,
+			}
+			for _, d := range r.Input {
+				rb.Shapeless.Ingredients = append(rb.Shapeless.Ingredients, c.descriptor(d))
+			}
+		case *protocol.FurnaceRecipe:
+			id, rb.Furnace = c.furnace(r, false)
+		case *protocol.FurnaceDataRecipe:
+			id, rb.Furnace = c.furnace(&r.FurnaceRecipe, true)
+		case *protocol.SmithingTransformRecipe:
+			id = r.RecipeID
+			rb.SmithingTransform = &recipeSmithingTransform{
+				Tags:     []string{r.Block},
+				Template: c.descriptor(r.Template),
+				Base:     c.descriptor(r.Base),
+				Addition: c.descriptor(r.Addition),
+				Result:   c.stack(r.Result),
+			}
+		case *protocol.SmithingTrimRecipe:
+			id = r.RecipeID
+			rb.SmithingTrim = &recipeSmithingTrim{
+				Tags:     []string{r.Block},
+				Template: c.descriptor(r.Template),
+				Base:     c.descriptor(r.Base),
+				Addition: c.descriptor(r.Addition),
+			}
+		default:
+			// multi, shulker box and chemistry recipes are built into the game
+			continue
+		}
+		if c.err != nil {
+			skipped = append(skipped, fmt.Errorf("recipe %d %q: %w", i, id, c.err))
+			continue
+		}
+		if c.namespace == "" {
+			continue
+		}
+
+		identifier := id
+		if !strings.Contains(identifier, ":") {
+			identifier = c.namespace + ":" + identifier
+		}
+		description := recipeDescription{Identifier: identifier}
+		switch {
+		case rb.Shaped != nil:
+			rb.Shaped.Description = description
+		case rb.Shapeless != nil:
+			rb.Shapeless.Description = description
+		case rb.Furnace != nil:
+			rb.Furnace.Description = description
+		case rb.SmithingTransform != nil:
+			rb.SmithingTransform.Description = description
+		case rb.SmithingTrim != nil:
+			rb.SmithingTrim.Description = description
+		}
+		bp.recipes[identifier] = &rb
+	}
+	return skipped
+}
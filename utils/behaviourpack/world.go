@@ -0,0 +1,146 @@
+package behaviourpack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/df-mc/goleveldb/leveldb/opt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+)
+
+func readPacks(folder string) ([]resource.Pack, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var packs []resource.Pack
+	for _, entry := range entries {
+		pack, err := resource.ReadPath(path.Join(folder, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
+// AskWorldPath asks for the world folder if worldPath is empty and cleans the path, false if the user cancelled
+func AskWorldPath(ctx context.Context, worldPath string) (string, bool) {
+	if worldPath == "" {
+		var cancelled bool
+		worldPath, cancelled = utils.UserInput(ctx, "World Path: ", func(s string) bool {
+			st, err := os.Stat(s)
+			if err != nil {
+				return false
+			}
+			return st.IsDir()
+		})
+		if cancelled {
+			return "", false
+		}
+	}
+	return path.Clean(strings.ReplaceAll(worldPath, "\\", "/")), true
+}
+
+// OpenWorld opens the database of a world folder, blocks has to know the custom blocks of the world
+func OpenWorld(worldPath string, blocks world.BlockRegistry, readOnly bool) (*mcdb.DB, error) {
+	if worldPath == "" {
+		return nil, fmt.Errorf("missing -world")
+	}
+	return mcdb.Config{
+		Log:    slog.Default(),
+		Blocks: blocks,
+		LDBOptions: &opt.Options{
+			ReadOnly: readOnly,
+		},
+	}.Open(worldPath)
+}
+
+var dimensionNames = map[string]int{
+	"overworld": 0,
+	"nether":    1,
+	"end":       2,
+}
+
+// ParseDimension takes a dimension name or id
+func ParseDimension(s string) (int, error) {
+	id, ok := dimensionNames[strings.ToLower(s)]
+	if !ok {
+		var err error
+		id, err = strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("unknown dimension %q, expected overworld, nether, end or an id", s)
+		}
+	}
+	if _, ok := world.DimensionByID(id); !ok {
+		return 0, fmt.Errorf("unknown dimension id %d", id)
+	}
+	return id, nil
+}
+
+// DimensionName returns the name of a dimension id
+func DimensionName(id int) string {
+	for name, i := range dimensionNames {
+		if i == id {
+			return name
+		}
+	}
+	return fmt.Sprintf("dimension %d", id)
+}
+
+// ReadWorldPacks reads the resource and behaviour packs saved in a world folder
+func ReadWorldPacks(worldPath string) (resourcePacks, behaviorPacks []resource.Pack, err error) {
+	resourcePacks, err = readPacks(path.Join(worldPath, "resource_packs"))
+	if err != nil {
+		return nil, nil, err
+	}
+	behaviorPacks, err = readPacks(path.Join(worldPath, "behavior_packs"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resourcePacks, behaviorPacks, nil
+}
+
+// BlockEntries returns the custom blocks defined in behaviour packs
+func BlockEntries(behaviorPacks []resource.Pack) ([]protocol.BlockEntry, error) {
+	var entries []protocol.BlockEntry
+	for _, pack := range behaviorPacks {
+		blockFiles, err := fs.Glob(pack, "blocks/**/*.json")
+		if err != nil {
+			return nil, err
+		}
+		for _, bff := range blockFiles {
+			f, err := pack.Open(bff)
+			if err != nil {
+				return nil, err
+			}
+			var blockBehaviour BlockBehaviour
+			err = json.NewDecoder(f).Decode(&blockBehaviour)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			block := blockBehaviour.MinecraftBlock
+
+			entries = append(entries, protocol.BlockEntry{
+				Name: block.Description.Identifier,
+				Properties: map[string]any{
+					"components": block.Components,
+				},
+			})
+		}
+	}
+	return entries, nil
+}
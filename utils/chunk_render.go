@@ -54,32 +54,31 @@ func (cr *ChunkRenderer) blockColorAt(c *chunk.Chunk, x uint8, y int16, z uint8)
 		return blockColor
 	}
 
+	blockColor = cr.BlockColor(b)
+	if blockColor.A != 0xff {
+		blockColor = BlendColors(cr.blockColorAt(c, x, y-1, z), blockColor)
+	}
+	return blockColor
+}
+
+// BlockColor returns the map colour of a block, custom blocks use the colours resolved from the resource packs
+func (cr *ChunkRenderer) BlockColor(b world.Block) (blockColor color.RGBA) {
 	if b2, ok := b.(world.UnknownBlock); ok {
 		name, _ := b2.EncodeBlock()
 		customColor, ok := cr.customBlockColors[name]
 		if ok {
-			blockColor = customColor
-			goto haveColor
+			return customColor
 		}
-
-		blockColor = LookupColor(name)
-		goto haveColor
-	} else {
-		blockColor = b.Color()
+		return LookupColor(name)
 	}
 
-haveColor:
+	blockColor = b.Color()
 	if blockColor.R == 0xff && blockColor.G == 0x0 && blockColor.B == 0xff {
 		if updater.Version == "" {
 			name, props := b.EncodeBlock()
 			logrus.Infof("no color %s %v", name, props)
-			b.Color()
 		}
 	}
-
-	if blockColor.A != 0xff {
-		blockColor = BlendColors(cr.blockColorAt(c, x, y-1, z), blockColor)
-	}
 	return blockColor
 }
 
@@ -120,14 +119,34 @@ func (cr *ChunkRenderer) chunkGetColorAt(c *chunk.Chunk, x uint8, y int16, z uin
 
 type ChunkRenderer struct {
 	customBlockColors map[string]color.RGBA
+	colorsHash        [32]byte
+
+	// Mode selects how chunks are drawn
+	Mode RenderMode
+	// SliceY is the height RenderModeCave starts looking for a gap from
+	SliceY int16
+	// Biomes is used to look up the biomes for RenderModeBiome
+	Biomes *world.BiomeRegistry
 }
 
 func (cr *ChunkRenderer) ResolveColors(entries []protocol.BlockEntry, packs []resource.Pack) {
 	colors := ResolveColors(entries, packs)
 	cr.customBlockColors = colors
+	cr.colorsHash = hashColors(colors)
 }
 
 func (cr *ChunkRenderer) Chunk2Img(c *chunk.Chunk) *image.RGBA {
+	switch cr.Mode {
+	case RenderModeHillShade:
+		return cr.hillShadeImg(c)
+	case RenderModeBiome:
+		return cr.biomeImg(c)
+	case RenderModeLight:
+		return cr.lightImg(c)
+	case RenderModeCave:
+		return cr.caveImg(c)
+	}
+
 	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
 	hm := c.HeightMapWithWater()
 
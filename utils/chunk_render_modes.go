@@ -0,0 +1,359 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+)
+
+// RenderMode is the style ChunkRenderer draws chunks in
+type RenderMode int
+
+const (
+	// RenderModeTopDown draws the colour of the highest block
+	RenderModeTopDown RenderMode = iota
+	// RenderModeHillShade draws the top down colours lit from the north west using the heightmap
+	RenderModeHillShade
+	// RenderModeBiome draws the biome of the highest block
+	RenderModeBiome
+	// RenderModeLight draws the block light level above the highest block
+	RenderModeLight
+	// RenderModeCave draws the floor of the first air gap below ChunkRenderer.SliceY
+	RenderModeCave
+)
+
+var renderModeNames = []string{
+	RenderModeTopDown:   "topdown",
+	RenderModeHillShade: "hillshade",
+	RenderModeBiome:     "biome",
+	RenderModeLight:     "light",
+	RenderModeCave:      "cave",
+}
+
+func (m RenderMode) String() string {
+	if int(m) < len(renderModeNames) {
+		return renderModeNames[m]
+	}
+	return fmt.Sprintf("RenderMode(%d)", int(m))
+}
+
+// RenderModeNames returns the names accepted by ParseRenderMode
+func RenderModeNames() []string {
+	return renderModeNames
+}
+
+// ParseRenderMode returns the render mode with the name
+func ParseRenderMode(name string) (RenderMode, error) {
+	for i, n := range renderModeNames {
+		if n == strings.ToLower(name) {
+			return RenderMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown render mode %q, expected one of %s", name, strings.Join(renderModeNames, ", "))
+}
+
+func shadeColor(c color.RGBA, amount int) color.RGBA {
+	clamp := func(v int) uint8 {
+		return uint8(max(0, min(255, v)))
+	}
+	return color.RGBA{
+		R: clamp(int(c.R) + amount),
+		G: clamp(int(c.G) + amount),
+		B: clamp(int(c.B) + amount),
+		A: c.A,
+	}
+}
+
+func (cr *ChunkRenderer) hillShadeImg(c *chunk.Chunk) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	hm := c.HeightMapWithWater()
+
+	// neighbours outside of the chunk are not available, use the edge instead
+	slope := func(x, z uint8, dx, dz int) float64 {
+		x1, z1 := max(int(x)-dx, 0), max(int(z)-dz, 0)
+		x2, z2 := min(int(x)+dx, 15), min(int(z)+dz, 15)
+		dist := float64(x2 - x1 + z2 - z1)
+		return float64(hm.At(uint8(x2), uint8(z2))-hm.At(uint8(x1), uint8(z1))) / dist
+	}
+
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			// light comes from the north west, slopes facing it are brighter
+			s := -(slope(x, z, 1, 0) + slope(x, z, 0, 1))
+			amount := int(math.Round(max(-3, min(3, s)) * 20))
+			img.SetRGBA(int(x), int(z), shadeColor(cr.chunkGetColorAt(c, x, hm.At(x, z), z), amount))
+		}
+	}
+	return img
+}
+
+// biomeColors are the colours vanilla biomes are drawn with, variants fall back to their base biome
+var biomeColors = map[string]color.RGBA{
+	"ocean":                    {0x00, 0x00, 0x70, 0xff},
+	"deep_ocean":               {0x00, 0x00, 0x30, 0xff},
+	"warm_ocean":               {0x00, 0x00, 0xac, 0xff},
+	"deep_warm_ocean":          {0x00, 0x00, 0x50, 0xff},
+	"lukewarm_ocean":           {0x00, 0x00, 0x90, 0xff},
+	"deep_lukewarm_ocean":      {0x00, 0x00, 0x40, 0xff},
+	"cold_ocean":               {0x20, 0x20, 0x70, 0xff},
+	"deep_cold_ocean":          {0x20, 0x20, 0x38, 0xff},
+	"frozen_ocean":             {0x70, 0x70, 0xd6, 0xff},
+	"deep_frozen_ocean":        {0x40, 0x40, 0x90, 0xff},
+	"legacy_frozen_ocean":      {0x90, 0x90, 0xa0, 0xff},
+	"river":                    {0x00, 0x00, 0xff, 0xff},
+	"frozen_river":             {0xa0, 0xa0, 0xff, 0xff},
+	"beach":                    {0xfa, 0xde, 0x55, 0xff},
+	"cold_beach":               {0xfa, 0xf0, 0xc0, 0xff},
+	"stone_beach":              {0xa2, 0xa2, 0x84, 0xff},
+	"plains":                   {0x8d, 0xb3, 0x60, 0xff},
+	"sunflower_plains":         {0xb5, 0xdb, 0x88, 0xff},
+	"desert":                   {0xfa, 0x94, 0x18, 0xff},
+	"forest":                   {0x05, 0x66, 0x21, 0xff},
+	"flower_forest":            {0x2d, 0x8e, 0x49, 0xff},
+	"birch_forest":             {0x30, 0x74, 0x44, 0xff},
+	"roofed_forest":            {0x40, 0x51, 0x1a, 0xff},
+	"taiga":                    {0x0b, 0x66, 0x59, 0xff},
+	"mega_taiga":               {0x59, 0x66, 0x51, 0xff},
+	"cold_taiga":               {0x31, 0x55, 0x4a, 0xff},
+	"ice_plains":               {0xff, 0xff, 0xff, 0xff},
+	"ice_plains_spikes":        {0xb4, 0xdc, 0xdc, 0xff},
+	"ice_mountains":            {0xa0, 0xa0, 0xa0, 0xff},
+	"extreme_hills":            {0x60, 0x60, 0x60, 0xff},
+	"extreme_hills_edge":       {0x72, 0x78, 0x9a, 0xff},
+	"extreme_hills_plus_trees": {0x50, 0x70, 0x50, 0xff},
+	"swampland":                {0x07, 0xf9, 0xb2, 0xff},
+	"mangrove_swamp":           {0x2c, 0xcc, 0x8e, 0xff},
+	"jungle":                   {0x53, 0x7b, 0x09, 0xff},
+	"jungle_edge":              {0x62, 0x8b, 0x17, 0xff},
+	"bamboo_jungle":            {0x76, 0x8e, 0x14, 0xff},
+	"savanna":                  {0xbd, 0xb2, 0x5f, 0xff},
+	"savanna_plateau":          {0xa7, 0x9d, 0x64, 0xff},
+	"mesa":                     {0xd9, 0x45, 0x15, 0xff},
+	"mesa_plateau":             {0xca, 0x8c, 0x65, 0xff},
+	"mesa_plateau_stone":       {0xb0, 0x97, 0x65, 0xff},
+	"mesa_bryce":               {0xff, 0x6d, 0x3d, 0xff},
+	"mushroom_island":          {0xff, 0x00, 0xff, 0xff},
+	"mushroom_island_shore":    {0xa0, 0x00, 0xff, 0xff},
+	"meadow":                   {0x83, 0xbb, 0x6d, 0xff},
+	"grove":                    {0x47, 0x72, 0x6c, 0xff},
+	"cherry_grove":             {0xff, 0xb7, 0xc5, 0xff},
+	"pale_garden":              {0x69, 0x6d, 0x95, 0xff},
+	"snowy_slopes":             {0xc4, 0xc4, 0xc4, 0xff},
+	"frozen_peaks":             {0xdc, 0xdc, 0xc8, 0xff},
+	"jagged_peaks":             {0xdc, 0xdc, 0xdc, 0xff},
+	"stony_peaks":              {0x7b, 0x8f, 0x74, 0xff},
+	"dripstone_caves":          {0x7b, 0x64, 0x52, 0xff},
+	"lush_caves":               {0x28, 0x3c, 0x00, 0xff},
+	"deep_dark":                {0x0e, 0x2a, 0x2f, 0xff},
+	"hell":                     {0xbf, 0x3b, 0x3b, 0xff},
+	"crimson_forest":           {0xdd, 0x08, 0x08, 0xff},
+	"warped_forest":            {0x49, 0x90, 0x7b, 0xff},
+	"soulsand_valley":          {0x5e, 0x38, 0x30, 0xff},
+	"basalt_deltas":            {0x40, 0x36, 0x36, 0xff},
+	"the_end":                  {0x80, 0x80, 0xff, 0xff},
+}
+
+// biomeColor returns the colour for a biome, biomes without a colour are
+// coloured by their temperature and rainfall
+func biomeColor(b world.Biome) color.RGBA {
+	name := b.String()
+	if c, ok := biomeColors[name]; ok {
+		return c
+	}
+	for _, suffix := range []string{"_mutated", "_hills", "_plus_trees"} {
+		name = strings.TrimSuffix(name, suffix)
+		if c, ok := biomeColors[name]; ok {
+			return c
+		}
+	}
+
+	temperature := max(0, min(1, b.Temperature()/2))
+	rainfall := max(0, min(1, b.Rainfall()))
+	return color.RGBA{
+		R: uint8(80 + temperature*150),
+		G: uint8(80 + rainfall*120),
+		B: uint8(200 - temperature*150),
+		A: 0xff,
+	}
+}
+
+func (cr *ChunkRenderer) biomeImg(c *chunk.Chunk) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	hm := c.HeightMapWithWater()
+	cache := make(map[uint32]color.RGBA)
+
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			id := c.Biome(x, hm.At(x, z), z)
+			col, ok := cache[id]
+			if !ok {
+				col = notFoundColor
+				if cr.Biomes != nil {
+					if b, found := cr.Biomes.BiomeByID(int(id)); found {
+						col = biomeColor(b)
+					}
+				}
+				cache[id] = col
+			}
+			img.SetRGBA(int(x), int(z), col)
+		}
+	}
+	return img
+}
+
+type lightSource struct {
+	x, y, z int
+	level   int
+}
+
+// lightSources finds all light emitting blocks in the chunk,
+// sub chunks without an emitter in their palette are skipped
+func lightSources(c *chunk.Chunk, br world.BlockRegistry) (sources []lightSource) {
+	emission := func(rid uint32) uint8 {
+		b, found := br.BlockByRuntimeID(rid)
+		if !found {
+			return 0
+		}
+		if e, ok := b.(world.LightEmitter); ok {
+			return e.LightEmissionLevel()
+		}
+		return 0
+	}
+
+	for i, sub := range c.Sub() {
+		if sub.Empty() {
+			continue
+		}
+		layer := sub.Layer(0)
+		palette := layer.Palette()
+		hasEmitter := false
+		for j := 0; j < palette.Len(); j++ {
+			if emission(palette.Value(uint16(j))) > 0 {
+				hasEmitter = true
+				break
+			}
+		}
+		if !hasEmitter {
+			continue
+		}
+		baseY := int(c.SubY(int16(i)))
+		for x := byte(0); x < 16; x++ {
+			for y := byte(0); y < 16; y++ {
+				for z := byte(0); z < 16; z++ {
+					if level := emission(layer.At(x, y, z)); level > 0 {
+						sources = append(sources, lightSource{
+							x: int(x), y: baseY + int(y), z: int(z),
+							level: int(level),
+						})
+					}
+				}
+			}
+		}
+	}
+	return sources
+}
+
+var darkColor = color.RGBA{0xc0, 0x20, 0x20, 0xff}
+
+// lightColor goes from dark blue at level 1 to yellow at 15, level 0 where mobs spawn is red
+func lightColor(level int) color.RGBA {
+	if level <= 0 {
+		return darkColor
+	}
+	t := float64(level-1) / 14
+	return color.RGBA{
+		R: uint8(0x30 + t*(0xff-0x30)),
+		G: uint8(0x30 + t*(0xf0-0x30)),
+		B: 0x80,
+		A: 0xff,
+	}
+}
+
+// lightImg draws the block light above the highest block, light is estimated from the emitters in the chunk
+// since chunks sent by servers dont include light, light from neighbouring chunks and occlusion are ignored
+func (cr *ChunkRenderer) lightImg(c *chunk.Chunk) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	hm := c.HeightMap()
+	var sources []lightSource
+	if br, ok := c.BlockRegistry.(world.BlockRegistry); ok {
+		sources = lightSources(c, br)
+	}
+
+	abs := func(v int) int {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			y := hm.At(x, z)
+			level := 0
+			for _, s := range sources {
+				dist := abs(s.x-int(x)) + abs(s.y-int(y)-1) + abs(s.z-int(z))
+				level = max(level, s.level-dist)
+			}
+			// keep the terrain recognizable under the light colour
+			base := cr.blockColorAt(c, x, y, z)
+			gray := uint8((int(base.R) + int(base.G) + int(base.B)) / 3)
+			lc := lightColor(level)
+			lc.A = 160
+			img.SetRGBA(int(x), int(z), BlendColors(color.RGBA{gray, gray, gray, 0xff}, lc))
+		}
+	}
+	return img
+}
+
+var solidColor = color.RGBA{0x10, 0x10, 0x10, 0xff}
+
+// caveFloor returns the height of the first non air block below the first air gap under top
+func caveFloor(c *chunk.Chunk, br world.BlockRegistry, x uint8, top int16, z uint8) (int16, bool) {
+	isAir := func(y int16) bool {
+		b, found := br.BlockByRuntimeID(c.Block(x, y, z, 0))
+		if !found {
+			return false
+		}
+		_, air := b.(block.Air)
+		return air
+	}
+
+	bottom := int16(c.Range().Min())
+	y := min(top, int16(c.Range().Max()))
+	for ; y > bottom && !isAir(y); y-- {
+	}
+	for ; y > bottom && isAir(y); y-- {
+	}
+	if y <= bottom {
+		return 0, false
+	}
+	return y, true
+}
+
+func (cr *ChunkRenderer) caveImg(c *chunk.Chunk) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	br, ok := c.BlockRegistry.(world.BlockRegistry)
+	if !ok {
+		return img
+	}
+
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			y, ok := caveFloor(c, br, x, cr.SliceY, z)
+			if !ok {
+				img.SetRGBA(int(x), int(z), solidColor)
+				continue
+			}
+			// deeper floors are darker
+			depth := int(cr.SliceY - y)
+			img.SetRGBA(int(x), int(z), shadeColor(cr.blockColorAt(c, x, y, z), -min(depth*2, 100)))
+		}
+	}
+	return img
+}
@@ -0,0 +1,144 @@
+// Package dbkeys parses the keys of a bedrock leveldb world
+package dbkeys
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// tags of keys that belong to a chunk, the key is x, z, [dimension], tag, [sub chunk y]
+const (
+	TagData3D                = '+'
+	TagVersion               = ','
+	TagData2D                = '-'
+	TagLegacyData2D          = '.'
+	TagSubChunk              = '/'
+	TagLegacyTerrain         = '0'
+	TagBlockEntity           = '1'
+	TagEntity                = '2'
+	TagPendingTicks          = '3'
+	TagBlockExtraData        = '4'
+	TagBiomeState            = '5'
+	TagFinalizedState        = '6'
+	TagBorderBlocks          = '8'
+	TagHardcodedSpawnAreas   = '9'
+	TagRandomTicks           = ':'
+	TagChecksums             = ';'
+	TagGenerationSeed        = 'A'
+	TagBlendingBiomeHeight   = 'B'
+	TagMetaDataHash          = '?'
+	TagBlendingData          = '@'
+	TagActorDigestVersion    = '>'
+	TagLegacyVersion         = 'v'
+	TagConversionDataVersion = '<'
+)
+
+var chunkTags = map[byte]bool{
+	TagData3D: true, TagVersion: true, TagData2D: true, TagLegacyData2D: true, TagSubChunk: true,
+	TagLegacyTerrain: true, TagBlockEntity: true, TagEntity: true, TagPendingTicks: true,
+	TagBlockExtraData: true, TagBiomeState: true, TagFinalizedState: true, TagBorderBlocks: true,
+	TagHardcodedSpawnAreas: true, TagRandomTicks: true, TagChecksums: true, TagGenerationSeed: true,
+	TagBlendingBiomeHeight: true, TagMetaDataHash: true, TagBlendingData: true,
+	TagActorDigestVersion: true, TagLegacyVersion: true, TagConversionDataVersion: true,
+}
+
+// EntityIDsPrefix is followed by the chunk index, the value is the list of entity ids in the chunk
+const EntityIDsPrefix = "digp"
+
+// EntityPrefix is followed by the unique id of an entity, the value is its nbt
+const EntityPrefix = "actorprefix"
+
+// ChunkKey is a parsed key of chunk data
+type ChunkKey struct {
+	Pos       world.ChunkPos
+	Dimension int
+	Tag       byte
+	// SubY is the index of a sub chunk, only set for TagSubChunk
+	SubY int8
+}
+
+// isText reports if the key is printable, chunk keys of realistic positions always have a zero or 0xff byte
+// while named keys like map_12345 can otherwise look like one
+func isText(key []byte) bool {
+	for _, b := range key {
+		if b < 0x20 || b > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseChunkKey parses a key, false if it isnt a chunk key
+func ParseChunkKey(key []byte) (k ChunkKey, ok bool) {
+	if isText(key) {
+		return k, false
+	}
+	var tagAt int
+	switch len(key) {
+	case 9, 10:
+		tagAt = 8
+	case 13, 14:
+		tagAt = 12
+		k.Dimension = int(int32(binary.LittleEndian.Uint32(key[8:])))
+	default:
+		return k, false
+	}
+	k.Tag = key[tagAt]
+	if !chunkTags[k.Tag] {
+		return k, false
+	}
+	hasSubY := len(key) == tagAt+2
+	if hasSubY != (k.Tag == TagSubChunk) {
+		return k, false
+	}
+	if hasSubY {
+		k.SubY = int8(key[tagAt+1])
+	}
+	k.Pos = world.ChunkPos{
+		int32(binary.LittleEndian.Uint32(key[0:])),
+		int32(binary.LittleEndian.Uint32(key[4:])),
+	}
+	return k, true
+}
+
+// Index returns the key prefix of a chunk
+func Index(pos world.ChunkPos, dimension int) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(pos[0]))
+	b = binary.LittleEndian.AppendUint32(b, uint32(pos[1]))
+	if dimension != 0 {
+		b = binary.LittleEndian.AppendUint32(b, uint32(dimension))
+	}
+	return b
+}
+
+// ParseEntityIDsKey parses a digp key, false if key isnt one
+func ParseEntityIDsKey(key []byte) (pos world.ChunkPos, dimension int, ok bool) {
+	rest, ok := bytes.CutPrefix(key, []byte(EntityIDsPrefix))
+	if !ok || (len(rest) != 8 && len(rest) != 12) {
+		return pos, 0, false
+	}
+	pos = world.ChunkPos{
+		int32(binary.LittleEndian.Uint32(rest[0:])),
+		int32(binary.LittleEndian.Uint32(rest[4:])),
+	}
+	if len(rest) == 12 {
+		dimension = int(int32(binary.LittleEndian.Uint32(rest[8:])))
+	}
+	return pos, dimension, true
+}
+
+// EntityIDs decodes the value of a digp key
+func EntityIDs(value []byte) []int64 {
+	ids := make([]int64, 0, len(value)/8)
+	for i := 0; i+8 <= len(value); i += 8 {
+		ids = append(ids, int64(binary.LittleEndian.Uint64(value[i:])))
+	}
+	return ids
+}
+
+// EntityKey returns the key of the nbt of an entity
+func EntityKey(id int64) []byte {
+	return binary.LittleEndian.AppendUint64([]byte(EntityPrefix), uint64(id))
+}
@@ -0,0 +1,30 @@
+package dbkeys_test
+
+import (
+	"testing"
+
+	"github.com/bedrock-tool/bedrocktool/utils/dbkeys"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+func TestParseChunkKey(t *testing.T) {
+	pos := world.ChunkPos{-3, 12}
+
+	sub := append(dbkeys.Index(pos, 1), dbkeys.TagSubChunk, 0xfc)
+	k, ok := dbkeys.ParseChunkKey(sub)
+	if !ok || k.Pos != pos || k.Dimension != 1 || k.Tag != dbkeys.TagSubChunk || k.SubY != -4 {
+		t.Fatalf("unexpected sub chunk key %+v %v", k, ok)
+	}
+
+	version := append(dbkeys.Index(pos, 0), dbkeys.TagVersion)
+	k, ok = dbkeys.ParseChunkKey(version)
+	if !ok || k.Pos != pos || k.Dimension != 0 || k.Tag != dbkeys.TagVersion {
+		t.Fatalf("unexpected version key %+v %v", k, ok)
+	}
+
+	for _, key := range []string{"map_12345", "map_-1234", "scoreboard", "~local_player", "Overworld", "BiomeData"} {
+		if _, ok := dbkeys.ParseChunkKey([]byte(key)); ok {
+			t.Fatalf("%s parsed as a chunk key", key)
+		}
+	}
+}
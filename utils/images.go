@@ -54,3 +54,33 @@ func DrawImgScaledPos(dst *image.RGBA, src *image.RGBA, bottomLeft image.Point,
 		}
 	}
 }
+
+// DrawLine draws a line from p1 to p2 onto dst, pixels outside of dst are skipped
+func DrawLine(dst *image.RGBA, p1, p2 image.Point, c color.RGBA) {
+	dx, sx := p2.X-p1.X, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	dy, sy := p1.Y-p2.Y, 1
+	if dy > 0 {
+		dy, sy = -dy, -1
+	}
+	e := dx + dy
+	for {
+		if p1.In(dst.Rect) {
+			dst.SetRGBA(p1.X, p1.Y, BlendColors(dst.RGBAAt(p1.X, p1.Y), c))
+		}
+		if p1 == p2 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			p1.X += sx
+		}
+		if e2 <= dx {
+			e += dx
+			p1.Y += sy
+		}
+	}
+}
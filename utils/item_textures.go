@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadLang reads texts/<language>.lang from all packs, the first pack that has a key wins
+func LoadLang(packs []resource.Pack, language string) map[string]string {
+	out := make(map[string]string)
+	for _, pack := range packs {
+		content, err := fs.ReadFile(pack, "texts/"+language+".lang")
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				logrus.Warn(err)
+			}
+			continue
+		}
+		content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "##") {
+				continue
+			}
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			if i := strings.Index(value, "\t#"); i >= 0 {
+				value = value[:i]
+			}
+			key = strings.TrimSpace(key)
+			if _, ok := out[key]; !ok {
+				out[key] = strings.TrimRight(value, " \t\r")
+			}
+		}
+	}
+	return out
+}
+
+func loadItemTextures(f fs.FS) (map[string]string, error) {
+	content, err := fs.ReadFile(f, "textures/item_texture.json")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var m struct {
+		Data map[string]struct {
+			Textures any `json:"textures"`
+		} `json:"texture_data"`
+	}
+	err = ParseJson(content, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string)
+	for name, v := range m.Data {
+		switch textures := v.Textures.(type) {
+		case string:
+			out[name] = textures
+		case []any:
+			if len(textures) > 0 {
+				if texture, ok := textures[0].(string); ok {
+					out[name] = texture
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// ItemIcon is an icon texture read from a resource pack
+type ItemIcon struct {
+	// path of the texture in the pack, with extension
+	Path string
+	Data []byte
+}
+
+// ResolveItemIcons looks up the icon textures for items,
+// iconNames maps item identifiers to the short name used in item_texture.json
+func ResolveItemIcons(iconNames map[string]string, packs []resource.Pack) map[string]ItemIcon {
+	log := logrus.WithField("func", "ResolveItemIcons")
+
+	var merged mergedFS
+	textures := make(map[string]string)
+	for _, pack := range packs {
+		merged.fss = append(merged.fss, pack)
+		itemTextures, err := loadItemTextures(pack)
+		if err != nil {
+			log.Warn(err)
+			continue
+		}
+		for name, texture := range itemTextures {
+			if _, ok := textures[name]; !ok {
+				textures[name] = texture
+			}
+		}
+	}
+
+	icons := make(map[string]ItemIcon)
+	for item, iconName := range iconNames {
+		texturePath, ok := textures[iconName]
+		if !ok {
+			continue
+		}
+		for _, format := range []string{".png", ".tga"} {
+			data, err := fs.ReadFile(&merged, texturePath+format)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				log.Error(err)
+				break
+			}
+			icons[item] = ItemIcon{
+				Path: texturePath + format,
+				Data: data,
+			}
+			break
+		}
+	}
+	return icons
+}
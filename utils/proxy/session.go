@@ -28,6 +28,8 @@ type Session struct {
 	ctx       context.Context
 	cancelCtx context.CancelCauseFunc
 	commands  map[string]ingameCommand
+	// commandsLock guards commands, scripts can add commands from their own goroutine
+	commandsLock sync.RWMutex
 
 	// from proxy
 	withClient        bool
@@ -76,6 +78,8 @@ func NewSession(ctx context.Context) *Session {
 // AddCommand adds a command to the command handler
 func (s *Session) AddCommand(exec func([]string) bool, cmd protocol.Command) {
 	cmd.AliasesOffset = 0xffffffff
+	s.commandsLock.Lock()
+	defer s.commandsLock.Unlock()
 	s.commands[cmd.Name] = ingameCommand{exec, cmd}
 }
 
@@ -711,15 +715,20 @@ func (s *Session) commandHandlerPacketCB(pk packet.Packet, toServer bool, _ time
 	case *packet.CommandRequest:
 		cmd := strings.Split(_pk.CommandLine, " ")
 		name := cmd[0][1:]
-		if h, ok := s.commands[name]; ok {
+		s.commandsLock.RLock()
+		h, ok := s.commands[name]
+		s.commandsLock.RUnlock()
+		if ok {
 			pk = nil
 			h.Exec(cmd[1:])
 		}
 	case *packet.AvailableCommands:
+		s.commandsLock.RLock()
 		cmds := make([]protocol.Command, 0, len(s.commands))
 		for _, ic := range s.commands {
 			cmds = append(cmds, ic.Cmd)
 		}
+		s.commandsLock.RUnlock()
 		_pk.Commands = append(_pk.Commands, cmds...)
 	}
 	return pk, nil
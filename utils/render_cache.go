@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"maps"
+	"slices"
+	"sync/atomic"
+
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/sirupsen/logrus"
+)
+
+// renderVersion is increased when the way chunks are drawn changes, so old cached images are redrawn
+const renderVersion = 1
+
+// RenderCacheFolder is where the render cache of a world folder is kept,
+// next to the world so it is not included when the world is packed
+func RenderCacheFolder(worldFolder string) string {
+	return worldFolder + ".render_cache"
+}
+
+// RenderCache stores the rendered images of chunks with a hash of their content,
+// so chunks are only rendered again when they changed.
+type RenderCache struct {
+	db     *leveldb.DB
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// OpenRenderCache opens or creates the cache in folder
+func OpenRenderCache(folder string) (*RenderCache, error) {
+	db, err := leveldb.OpenFile(folder, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &RenderCache{db: db}, nil
+}
+
+func (rc *RenderCache) Close() error {
+	return rc.db.Close()
+}
+
+// Stats returns how many chunks were taken from the cache and how many had to be rendered
+func (rc *RenderCache) Stats() (hits, misses int64) {
+	return rc.hits.Load(), rc.misses.Load()
+}
+
+func renderCacheKey(dim int, pos world.ChunkPos) []byte {
+	var key [12]byte
+	binary.LittleEndian.PutUint32(key[0:], uint32(int32(dim)))
+	binary.LittleEndian.PutUint32(key[4:], uint32(pos[0]))
+	binary.LittleEndian.PutUint32(key[8:], uint32(pos[1]))
+	return key[:]
+}
+
+// chunkHash hashes the blocks and biomes of the chunk together with everything that changes how it is drawn,
+// blocks are hashed by their states so the runtime ids of the block registry dont matter
+func (cr *ChunkRenderer) chunkHash(c *chunk.Chunk) []byte {
+	h := sha256.New()
+	var header [16]byte
+	binary.LittleEndian.PutUint32(header[0:], renderVersion)
+	binary.LittleEndian.PutUint32(header[4:], uint32(cr.Mode))
+	binary.LittleEndian.PutUint32(header[8:], uint32(cr.SliceY))
+	h.Write(header[:])
+	h.Write(cr.colorsHash[:])
+
+	data := chunk.Encode(c, chunk.DiskEncoding)
+	for _, sub := range data.SubChunks {
+		h.Write(sub)
+	}
+	h.Write(data.Biomes)
+	return h.Sum(nil)
+}
+
+// hashColors hashes the custom block colours, sorted by name since map order is random
+func hashColors(colors map[string]color.RGBA) (sum [32]byte) {
+	h := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(colors)) {
+		c := colors[name]
+		h.Write([]byte(name))
+		h.Write([]byte{c.R, c.G, c.B, c.A})
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// Chunk2Img returns the cached image of the chunk if it didnt change, otherwise it is rendered and stored
+func (rc *RenderCache) Chunk2Img(cr *ChunkRenderer, dim int, pos world.ChunkPos, c *chunk.Chunk) *image.RGBA {
+	key := renderCacheKey(dim, pos)
+	sum := cr.chunkHash(c)
+
+	value, err := rc.db.Get(key, nil)
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		logrus.WithField("part", "RenderCache").Warn(err)
+	}
+	if err == nil && len(value) == len(sum)+16*16*4 && bytes.Equal(value[:len(sum)], sum) {
+		rc.hits.Add(1)
+		img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+		copy(img.Pix, value[len(sum):])
+		return img
+	}
+
+	rc.misses.Add(1)
+	img := cr.Chunk2Img(c)
+	err = rc.db.Put(key, append(sum, img.Pix...), nil)
+	if err != nil {
+		logrus.WithField("part", "RenderCache").Warn(err)
+	}
+	return img
+}
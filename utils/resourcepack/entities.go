@@ -0,0 +1,108 @@
+package resourcepack
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+)
+
+// FindClientEntities returns the identifiers of the client entities defined in a pack, mapped to their file
+func FindClientEntities(pack fs.FS) map[string]string {
+	found := make(map[string]string)
+	fs.WalkDir(pack, "entity", func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() || path.Ext(fpath) != ".json" {
+			return nil
+		}
+		data, err := fs.ReadFile(pack, fpath)
+		if err != nil {
+			return nil
+		}
+		var file struct {
+			ClientEntity struct {
+				Description struct {
+					Identifier string `json:"identifier"`
+				} `json:"description"`
+			} `json:"minecraft:client_entity"`
+		}
+		if err := utils.ParseJson(data, &file); err != nil {
+			return nil
+		}
+		if identifier := file.ClientEntity.Description.Identifier; identifier != "" {
+			found[identifier] = fpath
+		}
+		return nil
+	})
+	return found
+}
+
+var (
+	placeholderColor1 = color.RGBA{0xf8, 0x00, 0xf8, 0xff}
+	placeholderColor2 = color.RGBA{0x00, 0x00, 0x00, 0xff}
+)
+
+// AddPlaceholderEntity adds a checkered box the size of the entity for entities without a client definition,
+// so they are at least visible in the saved world
+func (p *Pack) AddPlaceholderEntity(identifier string, width, height float32) {
+	if width <= 0 {
+		width = 1
+	}
+	if height <= 0 {
+		height = 1
+	}
+	ns, name, ok := strings.Cut(identifier, ":")
+	if !ok {
+		ns, name = "minecraft", identifier
+	}
+
+	texture := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for y := range 16 {
+		for x := range 16 {
+			if (x/4+y/4)%2 == 0 {
+				texture.SetRGBA(x, y, placeholderColor1)
+			} else {
+				texture.SetRGBA(x, y, placeholderColor2)
+			}
+		}
+	}
+
+	w := float32(math.Round(float64(width * 16)))
+	h := float32(math.Round(float64(height * 16)))
+	bones, _ := json.Marshal([]any{
+		map[string]any{
+			"name":  "body",
+			"pivot": []float32{0, 0, 0},
+			"cubes": []any{
+				map[string]any{
+					"origin": []float32{-w / 2, 0, -w / 2},
+					"size":   []float32{w, h, w},
+					"uv":     []float32{0, 0},
+				},
+			},
+		},
+	})
+
+	p.AddEntity(ns, name, texture, &utils.SkinGeometryFile{
+		FormatVersion: "1.12.0",
+		Geometry: []utils.SkinGeometry{
+			{
+				Description: utils.SkinGeometryDescription{
+					Identifier:          "geometry." + ns + "." + name,
+					TextureWidth:        "16",
+					TextureHeight:       "16",
+					VisibleBoundsWidth:  float64(width) + 1,
+					VisibleBoundsHeight: float64(height) + 1,
+				},
+				Bones: bones,
+			},
+		},
+	}, false)
+}
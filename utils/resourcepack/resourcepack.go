@@ -112,11 +112,16 @@ func (p *Pack) AddEntity(dir, name string, texture *image.RGBA, geometry *utils.
 				Geometry: map[string]string{
 					"default": geometry.Geometry[0].Description.Identifier,
 				},
+				RenderControllers: []any{"controller.render.default"},
 			},
 		},
 	})
 }
 
+func (p *Pack) HasContent() bool {
+	return len(p.Files) > 0
+}
+
 func (p *Pack) AddPlayer(id string, skinTexture *image.NRGBA, capeTexture *image.NRGBA, capeID string, geometry *utils.SkinGeometryFile, isDefault bool) {
 	var skinName = path.Join("textures", "player", id)
 	var capeName = "textures/entity/cape_invisible"
@@ -278,21 +283,28 @@ func (p *Pack) WriteTo(w io.Writer) {
 	b.Flush()
 }
 
-func (p *Pack) WriteToDir(fs utils.WriterFS, dir string) {
+func (p *Pack) WriteToDir(fs utils.WriterFS, dir string) error {
 	f, err := fs.Create(path.Join(dir, "manifest.json"))
 	if err != nil {
-		panic(err)
+		return err
 	}
-	_ = json.NewEncoder(f).Encode(&p.Manifest)
+	err = json.NewEncoder(f).Encode(&p.Manifest)
 	f.Close()
+	if err != nil {
+		return err
+	}
 
 	for name, content := range p.Files {
 		fullName := path.Join(dir, name)
 		f, err := fs.Create(fullName)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		f.Write(content)
+		_, err = f.Write(content)
 		f.Close()
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
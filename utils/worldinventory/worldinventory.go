@@ -0,0 +1,258 @@
+package worldinventory
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/nbtconv"
+	"github.com/df-mc/dragonfly/server/item"
+	_ "github.com/df-mc/dragonfly/server/item/enchantment"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+)
+
+// Row is one item stack in a container
+type Row struct {
+	Dimension int    `json:"dimension"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Z         int    `json:"z"`
+	Container string `json:"container"`
+	// ContainerName is the custom name of the container
+	ContainerName string `json:"container_name,omitempty"`
+	// Slot is the slot in the container, items inside of shulker boxes and bundles
+	// have the slots of the items they are in before theirs, 3/12
+	Slot         string   `json:"slot"`
+	Item         string   `json:"item"`
+	Count        int      `json:"count"`
+	Damage       int      `json:"damage"`
+	Enchantments []string `json:"enchantments,omitempty"`
+	DisplayName  string   `json:"display_name,omitempty"`
+	Lore         []string `json:"lore,omitempty"`
+}
+
+// itemListKeys are the tags that hold the items inside of an item, Items for shulker boxes
+// and storage_item_component_content for bundles
+var itemListKeys = []string{"Items", "storage_item_component_content"}
+
+// asSlice converts the different list types nbt decoding can produce to []any
+func asSlice(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, m := range v {
+			out[i] = m
+		}
+		return out
+	}
+	return nil
+}
+
+// enchantmentName formats an enchantment the same way as the ones of items dragonfly knows, unknown ids stay numbers
+func enchantmentName(id, level int) string {
+	if t, ok := item.EnchantmentByID(id); ok {
+		return fmt.Sprintf("%s %d", t.Name(), level)
+	}
+	return fmt.Sprintf("%d %d", id, level)
+}
+
+// fromNBT fills the item fields of a row, items dragonfly knows are decoded with nbtconv,
+// the raw nbt is used for custom items
+func fromNBT(data map[string]any) Row {
+	var r Row
+	stack := nbtconv.Item(data, nil)
+	if !stack.Empty() {
+		r.Item, _ = stack.Item().EncodeItem()
+		r.Count = stack.Count()
+		r.Damage = stack.MaxDurability() - stack.Durability()
+		if stack.MaxDurability() < 0 {
+			r.Damage = int(nbtconv.Int16(data, "Damage"))
+		}
+		for _, e := range stack.Enchantments() {
+			r.Enchantments = append(r.Enchantments, fmt.Sprintf("%s %d", e.Type().Name(), e.Level()))
+		}
+		r.DisplayName = stack.CustomName()
+		r.Lore = stack.Lore()
+		return r
+	}
+
+	r.Item = nbtconv.String(data, "Name")
+	r.Count = int(nbtconv.Uint8(data, "Count"))
+	r.Damage = int(nbtconv.Int16(data, "Damage"))
+	tag, _ := data["tag"].(map[string]any)
+	if tag == nil {
+		return r
+	}
+	if damage, ok := tag["Damage"].(int32); ok {
+		r.Damage = int(damage)
+	}
+	for _, e := range asSlice(tag["ench"]) {
+		if e, ok := e.(map[string]any); ok {
+			r.Enchantments = append(r.Enchantments, enchantmentName(int(nbtconv.Int16(e, "id")), int(nbtconv.Int16(e, "lvl"))))
+		}
+	}
+	if display, ok := tag["display"].(map[string]any); ok {
+		r.DisplayName = nbtconv.String(display, "Name")
+		for _, line := range asSlice(display["Lore"]) {
+			if line, ok := line.(string); ok {
+				r.Lore = append(r.Lore, line)
+			}
+		}
+	}
+	return r
+}
+
+// fromItems returns the rows of a list of items and the items inside of them
+func fromItems(items []any, parentSlot string) []Row {
+	var rows []Row
+	for i, it := range items {
+		data, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		slot := i
+		if s, ok := data["Slot"].(uint8); ok {
+			slot = int(s)
+		}
+		slotPath := strconv.Itoa(slot)
+		if parentSlot != "" {
+			slotPath = parentSlot + "/" + slotPath
+		}
+
+		r := fromNBT(data)
+		if r.Item == "" || r.Item == "minecraft:air" {
+			continue
+		}
+		r.Slot = slotPath
+		rows = append(rows, r)
+
+		if tag, ok := data["tag"].(map[string]any); ok {
+			for _, key := range itemListKeys {
+				rows = append(rows, fromItems(asSlice(tag[key]), slotPath)...)
+			}
+		}
+	}
+	return rows
+}
+
+// FromBlockEntity returns the items in a container or item frame, position is left empty
+func FromBlockEntity(data map[string]any) []Row {
+	id, _ := data["id"].(string)
+	items := asSlice(data["Items"])
+	if item, ok := data["Item"].(map[string]any); ok {
+		items = append(items, item)
+	}
+	rows := fromItems(items, "")
+	name, _ := data["CustomName"].(string)
+	for i := range rows {
+		rows[i].Container = id
+		rows[i].ContainerName = name
+	}
+	return rows
+}
+
+// FromColumn returns the items of all block entities in a column
+func FromColumn(dimension int, col *chunk.Column) []Row {
+	var rows []Row
+	for _, be := range col.BlockEntities {
+		for _, r := range FromBlockEntity(be.Data) {
+			r.Dimension = dimension
+			r.X, r.Y, r.Z = be.Pos.X(), be.Pos.Y(), be.Pos.Z()
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// FromDB returns the items of all containers in a world
+func FromDB(db *mcdb.DB) ([]Row, error) {
+	var rows []Row
+	it := db.NewColumnIterator(nil)
+	defer it.Release()
+	for it.Next() {
+		dimension, _ := world.DimensionID(it.Dimension())
+		rows = append(rows, FromColumn(dimension, it.Column())...)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	Sort(rows)
+	return rows, nil
+}
+
+// Sort orders rows by dimension and position, keeping the order of slots
+func Sort(rows []Row) {
+	slices.SortStableFunc(rows, func(a, b Row) int {
+		return cmp.Or(
+			cmp.Compare(a.Dimension, b.Dimension),
+			cmp.Compare(a.X, b.X),
+			cmp.Compare(a.Z, b.Z),
+			cmp.Compare(a.Y, b.Y),
+		)
+	})
+}
+
+// WriteJSON writes the rows as a json array
+func WriteJSON(w io.Writer, rows []Row) error {
+	if rows == nil {
+		rows = []Row{}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(rows)
+}
+
+// WriteCSV writes the rows as csv with a header row, lists are joined with ; and lore lines with |
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"dimension", "x", "y", "z", "container", "container_name", "slot", "item", "count", "damage", "enchantments", "display_name", "lore"})
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		err := cw.Write([]string{
+			strconv.Itoa(r.Dimension),
+			strconv.Itoa(r.X),
+			strconv.Itoa(r.Y),
+			strconv.Itoa(r.Z),
+			r.Container,
+			r.ContainerName,
+			r.Slot,
+			r.Item,
+			strconv.Itoa(r.Count),
+			strconv.Itoa(r.Damage),
+			strings.Join(r.Enchantments, ";"),
+			r.DisplayName,
+			strings.Join(r.Lore, "|"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteFile writes the rows to filename, as csv if it ends with .csv and json otherwise
+func WriteFile(filename string, rows []Row) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return WriteCSV(f, rows)
+	}
+	return WriteJSON(f, rows)
+}
@@ -0,0 +1,76 @@
+package worldinventory_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bedrock-tool/bedrocktool/utils/worldinventory"
+)
+
+func TestFromBlockEntity(t *testing.T) {
+	rows := worldinventory.FromBlockEntity(map[string]any{
+		"id":         "Chest",
+		"CustomName": "Loot",
+		"Items": []any{
+			map[string]any{
+				"Name":  "myserver:coin",
+				"Count": uint8(12),
+				"Slot":  uint8(3),
+				"tag": map[string]any{
+					"display": map[string]any{
+						"Name": "Gold Coin",
+						"Lore": []any{"worth 5$"},
+					},
+				},
+			},
+			map[string]any{
+				"Name":  "myserver:sword",
+				"Count": uint8(1),
+				"Slot":  uint8(5),
+				"tag": map[string]any{
+					"ench": []any{
+						map[string]any{"id": int16(9), "lvl": int16(5)},
+						map[string]any{"id": int16(200), "lvl": int16(1)},
+					},
+				},
+			},
+			map[string]any{
+				"Name":  "myserver:backpack",
+				"Count": uint8(1),
+				"Slot":  uint8(7),
+				"tag": map[string]any{
+					"Items": []any{
+						map[string]any{"Name": "myserver:coin", "Count": uint8(2), "Slot": uint8(1)},
+					},
+					"storage_item_component_content": []any{
+						map[string]any{"Name": "myserver:gem", "Count": uint8(1)},
+					},
+				},
+			},
+		},
+	})
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 items, got %+v", rows)
+	}
+	if rows[0].Slot != "3" || rows[0].Count != 12 || rows[0].DisplayName != "Gold Coin" || rows[0].ContainerName != "Loot" {
+		t.Errorf("unexpected item %+v", rows[0])
+	}
+	if strings.Join(rows[1].Enchantments, ";") != "Sharpness 5;200 1" {
+		t.Errorf("unexpected enchantments %+v", rows[1].Enchantments)
+	}
+	if rows[3].Slot != "7/1" || rows[3].Item != "myserver:coin" || rows[3].Container != "Chest" {
+		t.Errorf("unexpected nested item %+v", rows[3])
+	}
+	if rows[4].Slot != "7/0" || rows[4].Item != "myserver:gem" {
+		t.Errorf("unexpected bundle item %+v", rows[4])
+	}
+
+	var buf bytes.Buffer
+	if err := worldinventory.WriteCSV(&buf, rows[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Chest,Loot,3,myserver:coin,12,0,,Gold Coin,worth 5$") {
+		t.Errorf("unexpected csv %q", buf.String())
+	}
+}
@@ -0,0 +1,255 @@
+package worldtext
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+)
+
+// Entry is one piece of text found in a block entity
+type Entry struct {
+	Dimension   int    `json:"dimension"`
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+	Z           int    `json:"z"`
+	Block       string `json:"block"`
+	BlockEntity string `json:"block_entity"`
+	Source      string `json:"source"`
+	Slot        int    `json:"slot"`
+	Title       string `json:"title,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Text        string `json:"text"`
+}
+
+// BlockStates resolves runtime ids to block names
+type BlockStates interface {
+	RuntimeIDToState(runtimeID uint32) (name string, properties map[string]any, found bool)
+}
+
+func isBook(name string) bool {
+	return name == "minecraft:written_book" || name == "minecraft:writable_book"
+}
+
+// fromItem returns the text of books in an item and in items stored inside it (shulker boxes, bundles)
+func fromItem(item map[string]any, slot int) []Entry {
+	name, _ := item["Name"].(string)
+	tag, _ := item["tag"].(map[string]any)
+	if tag == nil {
+		return nil
+	}
+
+	var entries []Entry
+	if isBook(name) {
+		var pages []string
+		for _, page := range asSlice(tag["pages"]) {
+			if page, ok := page.(map[string]any); ok {
+				if text, _ := page["text"].(string); text != "" {
+					pages = append(pages, text)
+				}
+			}
+		}
+		if len(pages) > 0 {
+			title, _ := tag["title"].(string)
+			author, _ := tag["author"].(string)
+			entries = append(entries, Entry{
+				Source: "book",
+				Slot:   slot,
+				Title:  title,
+				Author: author,
+				Text:   strings.Join(pages, "\n\n"),
+			})
+		}
+	}
+
+	for _, inner := range asSlice(tag["Items"]) {
+		if inner, ok := inner.(map[string]any); ok {
+			entries = append(entries, fromItem(inner, slot)...)
+		}
+	}
+	return entries
+}
+
+// asSlice converts the different list types nbt decoding can produce to []any
+func asSlice(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, m := range v {
+			out[i] = m
+		}
+		return out
+	}
+	return nil
+}
+
+// FromBlockEntity returns all text in the nbt of a block entity, position and block are left empty
+func FromBlockEntity(data map[string]any) []Entry {
+	id, _ := data["id"].(string)
+
+	var entries []Entry
+	switch id {
+	case "Sign", "HangingSign":
+		for _, side := range []struct{ key, source string }{
+			{"FrontText", "sign_front"},
+			{"BackText", "sign_back"},
+		} {
+			if t, ok := data[side.key].(map[string]any); ok {
+				if text, _ := t["Text"].(string); strings.TrimSpace(text) != "" {
+					entries = append(entries, Entry{Source: side.source, Slot: -1, Text: text})
+				}
+			}
+		}
+		// signs from before 1.19.80 only have one side
+		if text, _ := data["Text"].(string); len(entries) == 0 && strings.TrimSpace(text) != "" {
+			entries = append(entries, Entry{Source: "sign_front", Slot: -1, Text: text})
+		}
+
+	case "Lectern":
+		if book, ok := data["book"].(map[string]any); ok {
+			for _, e := range fromItem(book, -1) {
+				e.Source = "lectern"
+				entries = append(entries, e)
+			}
+		}
+
+	case "ItemFrame", "GlowItemFrame":
+		if item, ok := data["Item"].(map[string]any); ok {
+			entries = append(entries, fromItem(item, -1)...)
+		}
+
+	default:
+		for i, item := range asSlice(data["Items"]) {
+			item, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			slot := i
+			if s, ok := item["Slot"].(uint8); ok {
+				slot = int(s)
+			}
+			entries = append(entries, fromItem(item, slot)...)
+		}
+	}
+
+	for i := range entries {
+		entries[i].BlockEntity = id
+	}
+	return entries
+}
+
+// FromColumn returns the text of all block entities in a column
+func FromColumn(dimension int, col *chunk.Column, blocks BlockStates) []Entry {
+	var entries []Entry
+	for _, be := range col.BlockEntities {
+		found := FromBlockEntity(be.Data)
+		if len(found) == 0 {
+			continue
+		}
+
+		var blockName string
+		if col.Chunk != nil {
+			rid := col.Chunk.Block(uint8(be.Pos.X()&15), int16(be.Pos.Y()), uint8(be.Pos.Z()&15), 0)
+			blockName, _, _ = blocks.RuntimeIDToState(rid)
+		}
+
+		for _, e := range found {
+			e.Dimension = dimension
+			e.X, e.Y, e.Z = be.Pos.X(), be.Pos.Y(), be.Pos.Z()
+			e.Block = blockName
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// FromDB walks every column of a world and returns all text found in it
+func FromDB(db *mcdb.DB, blocks BlockStates) ([]Entry, error) {
+	var entries []Entry
+	it := db.NewColumnIterator(nil)
+	defer it.Release()
+	for it.Next() {
+		dimension, _ := world.DimensionID(it.Dimension())
+		entries = append(entries, FromColumn(dimension, it.Column(), blocks)...)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	Sort(entries)
+	return entries, nil
+}
+
+// Sort orders entries by dimension and position
+func Sort(entries []Entry) {
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(a.Dimension, b.Dimension),
+			cmp.Compare(a.X, b.X),
+			cmp.Compare(a.Z, b.Z),
+			cmp.Compare(a.Y, b.Y),
+		)
+	})
+}
+
+// WriteJSON writes the entries as a json array
+func WriteJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(entries)
+}
+
+// WriteCSV writes the entries as csv with a header row
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"dimension", "x", "y", "z", "block", "block_entity", "source", "slot", "title", "author", "text"})
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err := cw.Write([]string{
+			strconv.Itoa(e.Dimension),
+			strconv.Itoa(e.X),
+			strconv.Itoa(e.Y),
+			strconv.Itoa(e.Z),
+			e.Block,
+			e.BlockEntity,
+			e.Source,
+			strconv.Itoa(e.Slot),
+			e.Title,
+			e.Author,
+			e.Text,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteFile writes the entries to filename, as csv if it ends with .csv and json otherwise
+func WriteFile(filename string, entries []Entry) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return WriteCSV(f, entries)
+	}
+	return WriteJSON(f, entries)
+}
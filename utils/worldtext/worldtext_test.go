@@ -0,0 +1,59 @@
+package worldtext_test
+
+import (
+	"testing"
+
+	"github.com/bedrock-tool/bedrocktool/utils/worldtext"
+)
+
+func TestFromBlockEntity(t *testing.T) {
+	sign := worldtext.FromBlockEntity(map[string]any{
+		"id":        "Sign",
+		"FrontText": map[string]any{"Text": "Shop\nDiamonds 5$"},
+		"BackText":  map[string]any{"Text": ""},
+	})
+	if len(sign) != 1 || sign[0].Source != "sign_front" || sign[0].Text != "Shop\nDiamonds 5$" {
+		t.Fatalf("unexpected sign entries %+v", sign)
+	}
+
+	chest := worldtext.FromBlockEntity(map[string]any{
+		"id": "Chest",
+		"Items": []any{
+			map[string]any{
+				"Name": "minecraft:written_book",
+				"Slot": uint8(4),
+				"tag": map[string]any{
+					"title":  "Rules",
+					"author": "admin",
+					"pages": []any{
+						map[string]any{"text": "no griefing"},
+						map[string]any{"text": "be nice"},
+					},
+				},
+			},
+			map[string]any{
+				"Name": "minecraft:shulker_box",
+				"Slot": uint8(5),
+				"tag": map[string]any{
+					"Items": []any{
+						map[string]any{
+							"Name": "minecraft:writable_book",
+							"tag": map[string]any{
+								"pages": []any{map[string]any{"text": "notes"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if len(chest) != 2 {
+		t.Fatalf("expected 2 books, got %+v", chest)
+	}
+	if chest[0].Slot != 4 || chest[0].Title != "Rules" || chest[0].Text != "no griefing\n\nbe nice" {
+		t.Errorf("unexpected book %+v", chest[0])
+	}
+	if chest[1].Slot != 5 || chest[1].Text != "notes" || chest[1].BlockEntity != "Chest" {
+		t.Errorf("unexpected nested book %+v", chest[1])
+	}
+}